@@ -0,0 +1,137 @@
+// Package idclient 是 id-server 的 Go 客户端
+package idclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Client 通过 HTTP 调用 id-server
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// Option 客户端配置项
+type Option func(*Client)
+
+// WithHTTPClient 使用自定义的 http.Client
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// New 创建客户端，baseURL 形如 http://id-server:8082
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Parts ID解析结果
+type Parts struct {
+	ID           ID        `json:"id"`
+	Time         time.Time `json:"time"`
+	Timestamp    int64     `json:"timestamp"`
+	DatacenterID int64     `json:"datacenter_id"`
+	WorkerID     int64     `json:"worker_id"`
+	Sequence     int64     `json:"sequence"`
+}
+
+// Error id-server 返回的错误
+type Error struct {
+	StatusCode int
+	Code       string `json:"error"`
+	Message    string `json:"message"`
+	// Retryable 租约丢失、时钟回拨等暂时性错误，可稍后或换一个实例重试
+	Retryable bool `json:"retryable"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("id-server responded %d: %s %s", e.StatusCode, e.Code, e.Message)
+}
+
+// NextID 获取单个ID
+func (c *Client) NextID(ctx context.Context) (ID, error) {
+	var resp struct {
+		ID ID `json:"id"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/v1/ids/next", nil, &resp); err != nil {
+		return 0, err
+	}
+	return resp.ID, nil
+}
+
+// NextIDs 批量获取 count 个ID
+func (c *Client) NextIDs(ctx context.Context, count int) ([]ID, error) {
+	var resp struct {
+		IDs []ID `json:"ids"`
+	}
+	body := struct {
+		Count int `json:"count"`
+	}{count}
+	if err := c.do(ctx, http.MethodPost, "/api/v1/ids/batch", body, &resp); err != nil {
+		return nil, err
+	}
+	return resp.IDs, nil
+}
+
+// Decompose 解析ID
+func (c *Client) Decompose(ctx context.Context, id ID) (*Parts, error) {
+	var parts Parts
+	if err := c.do(ctx, http.MethodGet, "/api/v1/ids/decompose/"+id.String(), nil, &parts); err != nil {
+		return nil, err
+	}
+	return &parts, nil
+}
+
+// Health 检查服务是否可用
+func (c *Client) Health(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/health", nil, nil)
+}
+
+// do 发送请求，in 不为 nil 时以 JSON 作为请求体
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &Error{StatusCode: resp.StatusCode}
+		_ = json.NewDecoder(resp.Body).Decode(apiErr)
+		return apiErr
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,71 @@
+package idclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNextIDs(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/ids/batch" {
+			t.Errorf("got %s %s, want POST /api/v1/ids/batch", r.Method, r.URL.Path)
+		}
+		var body struct {
+			Count int `json:"count"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Count != 2 {
+			t.Errorf("body count = %d (%v), want 2", body.Count, err)
+		}
+		w.Write([]byte(`{"ids":["1","2"]}`))
+	}))
+	defer srv.Close()
+
+	ids, err := New(srv.URL).NextIDs(context.Background(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Fatalf("ids = %v, want [1 2]", ids)
+	}
+}
+
+func TestRetryableError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error":"node_lease_lost","message":"lost","retryable":true}`))
+	}))
+	defer srv.Close()
+
+	_, err := New(srv.URL).NextID(context.Background())
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want *Error", err)
+	}
+	if apiErr.StatusCode != http.StatusServiceUnavailable || apiErr.Code != "node_lease_lost" || !apiErr.Retryable {
+		t.Fatalf("err = %+v, want retryable 503 node_lease_lost", apiErr)
+	}
+}
+
+func TestID_JSON(t *testing.T) {
+	data, err := json.Marshal(ID(361368585391575048))
+	if err != nil || string(data) != `"361368585391575048"` {
+		t.Fatalf("Marshal = %s, %v, want a JSON string", data, err)
+	}
+	for _, in := range []string{`"361368585391575048"`, `361368585391575048`} {
+		var id ID
+		if err := json.Unmarshal([]byte(in), &id); err != nil || id != 361368585391575048 {
+			t.Errorf("Unmarshal(%s) = %d, %v", in, id, err)
+		}
+	}
+	var id ID
+	if err := json.Unmarshal([]byte(`"-1"`), &id); err == nil {
+		t.Error("Unmarshal(\"-1\") succeeded")
+	}
+}
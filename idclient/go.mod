@@ -0,0 +1,3 @@
+module github.com/shgang97/sys-collections/idclient
+
+go 1.25
@@ -0,0 +1,38 @@
+package idclient
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// ID id-server 发放的ID，JSON 中以字符串传输以免 JavaScript 丢失精度
+//
+// 客户端不依赖 snowflake 模块，需要拆解时可转换为 snowflake.ID 或调用 Decompose
+type ID int64
+
+// String 返回十进制字符串
+func (id ID) String() string {
+	return strconv.FormatInt(int64(id), 10)
+}
+
+// Int64 返回 int64 形式
+func (id ID) Int64() int64 {
+	return int64(id)
+}
+
+// MarshalJSON 编码为 JSON 字符串
+func (id ID) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(id.String())), nil
+}
+
+// UnmarshalJSON 同时接受字符串和数字形式
+func (id *ID) UnmarshalJSON(data []byte) error {
+	s := string(bytes.Trim(data, `"`))
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || v < 0 {
+		return fmt.Errorf("idclient: invalid id %s", data)
+	}
+	*id = ID(v)
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 type ServerConfig struct {
 	APIServer      APIConfig      `mapstructure:"api"`
 	RedirectServer RedirectConfig `mapstructure:"redirect"`
+	IDServer       IDConfig       `mapstructure:"id"`
 }
 
 type APIConfig struct {
@@ -14,12 +15,34 @@ type APIConfig struct {
 	Host    string `mapstructure:"host"`
 	Mode    string `mapstructure:"mode"`
 	BaseURL string `mapstructure:"base_url"`
+	// ReuseExisting 创建请求未指定 reuse_existing 时的默认值
+	ReuseExisting bool `mapstructure:"reuse_existing"`
 }
 
 type RedirectConfig struct {
-	Port int    `mapstructure:"port"`
-	Host string `mapstructure:"host"`
-	Mode string `mapstructure:"mode"`
+	Port     int                `mapstructure:"port"`
+	Host     string             `mapstructure:"host"`
+	Mode     string             `mapstructure:"mode"`
+	Password LinkPasswordConfig `mapstructure:"password"`
+	// TeaserURL 尚未到开始时间的链接跳转的预告页，{code} 替换为短码，为空时返回尚未生效
+	TeaserURL string `mapstructure:"teaser_url"`
+}
+
+// LinkPasswordConfig 密码保护链接配置，api-server 和 redirect-server 需使用相同的 secret
+type LinkPasswordConfig struct {
+	Secret        string        `mapstructure:"secret"`         // 访问凭证 Cookie 的签名密钥，为空时启动时随机生成，不能使用占位值 change-me-in-production
+	CookieTTL     time.Duration `mapstructure:"cookie_ttl"`     // 验证通过后免密访问的时长
+	MaxAttempts   int           `mapstructure:"max_attempts"`   // 每个短码在 lockout_window 内允许的失败次数
+	LockoutWindow time.Duration `mapstructure:"lockout_window"` // 失败次数的统计窗口
+}
+
+type IDConfig struct {
+	Port      int     `mapstructure:"port"`
+	Host      string  `mapstructure:"host"`
+	Mode      string  `mapstructure:"mode"`
+	MaxBatch  int     `mapstructure:"max_batch"`
+	NodeID    *int64  `mapstructure:"node_id"`    // 覆盖 id_generator.snowflake.node_id，避免与 api-server 使用相同节点
+	StateFile *string `mapstructure:"state_file"` // 覆盖 id_generator.snowflake.state_file，每个进程必须使用独立的状态文件
 }
 
 type DatabaseConfig struct {
@@ -42,12 +65,77 @@ type RedisConfig struct {
 }
 
 type IDGeneratorConfig struct {
-	Type      string          `mapstructure:"type"`
-	Snowflake SnowflakeConfig `mapstructure:"snowflake"`
+	Type      string               `mapstructure:"type"` // redis, redis_range, snowflake, segment
+	Redis     RedisGeneratorConfig `mapstructure:"redis"`
+	Snowflake SnowflakeConfig      `mapstructure:"snowflake"`
+	Segment   SegmentConfig        `mapstructure:"segment"`
+}
+
+type RedisGeneratorConfig struct {
+	Key               string  `mapstructure:"key"`
+	Step              int64   `mapstructure:"step"`               // redis_range 每次 INCRBY 预留的ID数量
+	PrefetchThreshold float64 `mapstructure:"prefetch_threshold"` // redis_range 当前段剩余比例不高于该值时后台预取下一段，默认 0.9
+}
+
+type SegmentConfig struct {
+	BizTag            string  `mapstructure:"biz_tag"`
+	PrefetchThreshold float64 `mapstructure:"prefetch_threshold"` // 当前号段剩余比例不高于该值时预取下一号段，默认 0.9
 }
 
 type SnowflakeConfig struct {
-	NodeID int64 `mapstructure:"node_id"`
+	NodeID           int64           `mapstructure:"node_id"`
+	DatacenterID     int64           `mapstructure:"datacenter_id"`
+	DatacenterBits   uint8           `mapstructure:"datacenter_bits"`
+	WorkerBits       uint8           `mapstructure:"worker_bits"`
+	SequenceBits     uint8           `mapstructure:"sequence_bits"`
+	RollbackBits     uint8           `mapstructure:"rollback_bits"`
+	RollbackStrategy string          `mapstructure:"rollback_strategy"` // fail, wait, borrow
+	MaxRollback      time.Duration   `mapstructure:"max_rollback"`
+	Clock            string          `mapstructure:"clock"` // wall, monotonic
+	LockFree         bool            `mapstructure:"lock_free"`
+	Lease            NodeLeaseConfig `mapstructure:"lease"`
+	StateFile        string          `mapstructure:"state_file"` // 为空时不持久化时间戳
+	PersistInterval  time.Duration   `mapstructure:"persist_interval"`
+	StartupWait      time.Duration   `mapstructure:"startup_wait"`
+}
+
+// NodeLeaseConfig 启用后忽略 node_id，启动时从Redis租用空闲的节点ID
+type NodeLeaseConfig struct {
+	Enabled   bool          `mapstructure:"enabled"`
+	KeyPrefix string        `mapstructure:"key_prefix"`
+	TTL       time.Duration `mapstructure:"ttl"`
+}
+
+type ShortCodeConfig struct {
+	Length    int    `mapstructure:"length"`     // 短码固定长度，不足时左侧填充，0 表示不填充
+	Alphabet  string `mapstructure:"alphabet"`   // base62、no_lookalike、base36、no_lookalike_lower 或自定义字母表
+	CheckChar bool   `mapstructure:"check_char"` // 在短码末尾追加 Luhn mod N 校验字符
+	// CaseInsensitive 整个部署的短码不区分大小写，统一以小写保存和查询，字母表不能包含大写字母
+	CaseInsensitive bool `mapstructure:"case_insensitive"`
+	// ReservedWords 保留的短码（不区分大小写），路由使用的路径前缀会自动加入
+	ReservedWords []string `mapstructure:"reserved_words"`
+	// DenylistFile 禁用词文件，每行一个词，短码中包含任意禁用词（不区分大小写）即不可用
+	DenylistFile string            `mapstructure:"denylist_file"`
+	Obfuscation  ObfuscationConfig `mapstructure:"obfuscation"`
+	Pool         CodePoolConfig    `mapstructure:"pool"`
+}
+
+// CodePoolConfig 预生成短码池配置，短码池存放在 Redis 列表中，由后台任务补充
+type CodePoolConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	Key            string        `mapstructure:"key"`
+	Size           int           `mapstructure:"size"`            // 补充到的目标数量
+	LowWater       int           `mapstructure:"low_water"`       // 低于该数量时开始补充
+	RefillBatch    int           `mapstructure:"refill_batch"`    // 每批生成的短码数量
+	RefillInterval time.Duration `mapstructure:"refill_interval"` // 定时检查间隔，取出短码时也会触发检查
+}
+
+// ObfuscationConfig 短码混淆配置，使用带密钥的 Feistel 置换打乱ID后再编码
+type ObfuscationConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Key     string `mapstructure:"key"`
+	Bits    int    `mapstructure:"bits"`   // 参与置换的低位位数，必须为偶数
+	Rounds  int    `mapstructure:"rounds"` // Feistel 轮数
 }
 
 type CacheConfig struct {
@@ -67,6 +155,7 @@ type Config struct {
 	Database    DatabaseConfig    `mapstructure:"database"`
 	Redis       RedisConfig       `mapstructure:"redis"`
 	IdGenerator IDGeneratorConfig `mapstructure:"id_generator"`
+	ShortCode   ShortCodeConfig   `mapstructure:"short_code"`
 	Cache       CacheConfig       `mapstructure:"cache"`
 	Log         LogConfig         `mapstructure:"log"`
 }
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"net/http"
+	"short-url-sys/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetGeoRules 获取链接的地域跳转规则
+// @Router /api/v1/links/{code}/rules [get]
+func (h *LinkHandler) GetGeoRules(c *gin.Context) {
+	resp, err := h.linkService.GetGeoRules(c.Request.Context(), c.Param("code"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// SetGeoRules 替换链接的全部地域跳转规则
+// @Router /api/v1/links/{code}/rules [put]
+func (h *LinkHandler) SetGeoRules(c *gin.Context) {
+	var req model.GeoRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Invalid request body",
+		})
+		return
+	}
+	resp, err := h.linkService.SetGeoRules(c.Request.Context(), c.Param("code"), req.Rules)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// AddGeoRule 添加地域跳转规则，已有相同国家和地区的规则时替换
+// @Router /api/v1/links/{code}/rules [post]
+func (h *LinkHandler) AddGeoRule(c *gin.Context) {
+	var req model.GeoRule
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Invalid request body",
+		})
+		return
+	}
+	resp, err := h.linkService.AddGeoRule(c.Request.Context(), c.Param("code"), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// DeleteGeoRules 删除 country、region 指定的规则，未指定 country 时删除全部规则
+// @Router /api/v1/links/{code}/rules [delete]
+func (h *LinkHandler) DeleteGeoRules(c *gin.Context) {
+	var req model.DeleteGeoRuleRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Invalid query parameters",
+		})
+		return
+	}
+	resp, err := h.linkService.DeleteGeoRules(c.Request.Context(), c.Param("code"), req.Country, req.Region)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"short-url-sys/internal/model"
+	"short-url-sys/internal/service/idgen"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shgang97/sys-collections/snowflake"
+)
+
+type IDHandler struct {
+	generator *idgen.Snowflake
+	maxBatch  int
+}
+
+func NewIDHandler(generator *idgen.Snowflake, maxBatch int) *IDHandler {
+	if maxBatch <= 0 {
+		maxBatch = 1000
+	}
+	return &IDHandler{
+		generator: generator,
+		maxBatch:  maxBatch,
+	}
+}
+
+// NextID 获取单个ID，每次调用都会消耗ID，因此使用 POST
+// @Router /api/v1/ids/next [post]
+func (h *IDHandler) NextID(c *gin.Context) {
+	id, err := h.generator.NextId(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, model.NextIDResponse{ID: snowflake.ID(id)})
+}
+
+// BatchIDs 批量获取ID
+// @Router /api/v1/ids/batch [post]
+func (h *IDHandler) BatchIDs(c *gin.Context) {
+	var req model.BatchIDRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Count > h.maxBatch {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error:   "invalid_count",
+			Message: "count must be between 1 and " + strconv.Itoa(h.maxBatch),
+		})
+		return
+	}
+
+	ids, err := h.generator.NextIds(c.Request.Context(), req.Count)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	resp := model.BatchIDResponse{IDs: make([]snowflake.ID, len(ids))}
+	for i, id := range ids {
+		resp.IDs[i] = snowflake.ID(id)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// DecomposeID 解析ID
+// @Router /api/v1/ids/decompose/{id} [get]
+func (h *IDHandler) DecomposeID(c *gin.Context) {
+	id, err := snowflake.ParseString(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error:   "invalid_id",
+			Message: "ID must be a non-negative integer",
+		})
+		return
+	}
+
+	parts := h.generator.Decompose(uint64(id))
+	c.JSON(http.StatusOK, model.DecomposeIDResponse{
+		ID:           id,
+		Time:         parts.Time,
+		Timestamp:    parts.Timestamp,
+		DatacenterID: parts.DatacenterId,
+		WorkerID:     parts.WorkId,
+		Sequence:     parts.Sequence,
+	})
+}
+
+// handleError 租约丢失、时钟回拨等暂时性错误返回 503，客户端可稍后或换一个实例重试，其他错误交给错误处理中间件
+func (h *IDHandler) handleError(c *gin.Context, err error) {
+	var resp model.RetryableErrorResponse
+	switch {
+	case errors.Is(err, idgen.ErrLeaseLost):
+		resp.Error = "node_lease_lost"
+		resp.Message = "Node ID lease lost, retry on another instance"
+	case errors.Is(err, idgen.ErrClockBackward):
+		resp.Error = "clock_backward"
+		resp.Message = "Clock moved backwards, retry later"
+		var backward *snowflake.ClockBackwardError
+		if errors.As(err, &backward) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(backward.Offset.Seconds()))))
+		}
+	case errors.Is(err, snowflake.ErrNodeClosed):
+		resp.Error = "node_closed"
+		resp.Message = "ID server is shutting down, retry on another instance"
+	default:
+		c.Error(err)
+		return
+	}
+	resp.Retryable = true
+	c.JSON(http.StatusServiceUnavailable, resp)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"short-url-sys/internal/model"
+	"short-url-sys/internal/pkg/errors"
 	linkSrc "short-url-sys/internal/service/link"
 
 	"github.com/gin-gonic/gin"
@@ -33,6 +34,20 @@ func (h *LinkHandler) CreateShortURL(c *gin.Context) {
 
 	link, err := h.linkService.CreateShortURL(c.Request.Context(), &req)
 	if err != nil {
+		// 自定义短码已被占用时返回可用的替代短码
+		if err == errors.ErrShortCodeExists && req.CustomCode != nil {
+			caseInsensitive := req.CaseInsensitive != nil && *req.CaseInsensitive
+			if suggestions, sErr := h.linkService.SuggestCodes(c.Request.Context(), *req.CustomCode, caseInsensitive); sErr == nil {
+				c.JSON(http.StatusConflict, model.ShortCodeConflictResponse{
+					ErrorResponse: model.ErrorResponse{
+						Error:   "short_code_exists",
+						Message: "Short code already exists",
+					},
+					Suggestions: suggestions,
+				})
+				return
+			}
+		}
 		c.Error(err)
 		return
 	}
@@ -48,6 +63,25 @@ func (h *LinkHandler) CreateShortURL(c *gin.Context) {
 	c.JSON(http.StatusCreated, resp)
 }
 
+// CheckAvailability 检查自定义短码是否可用
+// @Router /api/v1/links/availability/{code} [get]
+func (h *LinkHandler) CheckAvailability(c *gin.Context) {
+	var req model.AvailabilityRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Invalid query parameters",
+		})
+		return
+	}
+	resp, err := h.linkService.CheckAvailability(c.Request.Context(), c.Param("code"), req.CaseInsensitive)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
 // GetLinkInfo
 // @Router /api/v1/links/{code} [get]
 func (h *LinkHandler) GetLinkInfo(c *gin.Context) {
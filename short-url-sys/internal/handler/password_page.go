@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"short-url-sys/internal/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+var passwordPage = template.Must(template.New("password").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Password required</title>
+<style>
+body { font-family: sans-serif; display: flex; justify-content: center; margin-top: 15vh; }
+form { width: 320px; }
+input { width: 100%; box-sizing: border-box; padding: 8px; margin: 8px 0; }
+.error { color: #c0392b; }
+</style>
+</head>
+<body>
+<form method="post" autocomplete="off">
+<h3>This link is password protected</h3>
+{{if .Message}}<p class="error">{{.Message}}</p>{{end}}
+{{if not .Locked}}<input type="password" name="password" placeholder="Password" autofocus required>
+<input type="submit" value="Continue">{{end}}
+</form>
+</body>
+</html>
+`))
+
+// renderPasswordPage 密码相关的错误显示密码输入页，其他错误返回 false 交由错误处理中间件处理
+func renderPasswordPage(c *gin.Context, err error) bool {
+	status := http.StatusUnauthorized
+	data := struct {
+		Message string
+		Locked  bool
+	}{}
+	switch err {
+	case errors.ErrPasswordRequired:
+	case errors.ErrPasswordInvalid:
+		data.Message = "Incorrect password, please try again."
+	case errors.ErrTooManyAttempts:
+		status = http.StatusTooManyRequests
+		data.Message = "Too many failed attempts, please try again later."
+		data.Locked = true
+	default:
+		return false
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Header("Cache-Control", "no-store")
+	c.Status(status)
+	if err := passwordPage.Execute(c.Writer, data); err != nil {
+		log.Printf("An error: %v occurred while rendering password page\n", err)
+	}
+	return true
+}
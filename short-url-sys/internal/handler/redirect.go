@@ -4,25 +4,49 @@ import (
 	"log"
 	"net/http"
 	"short-url-sys/internal/model"
+	"short-url-sys/internal/service/link"
 	"short-url-sys/internal/service/redirect"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	// passwordHeader 非浏览器客户端通过该请求头提交访问密码
+	passwordHeader = "X-Link-Password"
+	// accessCookie 验证密码后下发的访问凭证，Path 限定为短链路径
+	accessCookie = "link_access"
+)
+
 type RedirectHandler struct {
 	redirectService redirect.Service
+	codeGenerator   *link.ShortCodeGenerator
 }
 
-func NewRedirectHandler(redirectService redirect.Service) *RedirectHandler {
+func NewRedirectHandler(redirectService redirect.Service, codeGenerator *link.ShortCodeGenerator) *RedirectHandler {
 	return &RedirectHandler{
 		redirectService: redirectService,
+		codeGenerator:   codeGenerator,
 	}
 }
 
 // Redirect
 // @Router /{code} [get]
 func (h *RedirectHandler) Redirect(c *gin.Context) {
+	// 密码只接受请求头或密码页提交的表单，不从查询参数读取，避免出现在访问日志、浏览器历史和 Referer 中
+	password := c.GetHeader(passwordHeader)
+	// 通过请求头提交密码的是接口调用方，错误时返回 JSON，其余浏览器访问显示密码输入页
+	prompt := password == "" && c.NegotiateFormat(gin.MIMEHTML, gin.MIMEJSON) == gin.MIMEHTML
+	h.redirect(c, password, prompt)
+}
+
+// SubmitPassword 密码输入页提交密码
+// @Router /{code} [post]
+func (h *RedirectHandler) SubmitPassword(c *gin.Context) {
+	h.redirect(c, c.PostForm("password"), true)
+}
+
+func (h *RedirectHandler) redirect(c *gin.Context, password string, prompt bool) {
 	shortCode := c.Param("code")
 	if shortCode == "" {
 		c.JSON(http.StatusBadRequest, model.ErrorResponse{
@@ -32,6 +56,15 @@ func (h *RedirectHandler) Redirect(c *gin.Context) {
 		return
 	}
 
+	// 不区分大小写的部署统一使用小写短码
+	shortCode = h.codeGenerator.Canonical(shortCode)
+
+	// 校验字符不匹配的短码一定不存在，无需查询缓存和数据库
+	if err := h.codeGenerator.Validate(shortCode); err != nil {
+		c.Error(err)
+		return
+	}
+
 	// 构建重定向请求
 	ip := getClientIP(c)
 	req := redirect.RedirectRequest{
@@ -39,18 +72,42 @@ func (h *RedirectHandler) Redirect(c *gin.Context) {
 		UserAgent: c.Request.UserAgent(),
 		Referer:   c.Request.Referer(),
 		// TODO 简化实现，实际应该使用IP地理定位服务
-		Country: getCountryFromIP(ip),
-		Region:  "",
-		City:    "",
+		Country:  getCountryFromIP(ip),
+		Region:   "",
+		City:     "",
+		Password: password,
+	}
+	if token, err := c.Cookie(accessCookie); err == nil {
+		req.AccessToken = token
 	}
-	longURL, err := h.redirectService.Redirect(c.Request.Context(), shortCode, &req)
+	result, err := h.redirectService.Redirect(c.Request.Context(), shortCode, &req)
 	if err != nil {
+		if prompt && renderPasswordPage(c, err) {
+			return
+		}
 		c.Error(err)
 		return
 	}
-	// 使用 302 临时重定向，便于统计
-	log.Printf("Redirect URL: %s", longURL)
-	c.Redirect(http.StatusFound, longURL)
+
+	if result.AccessToken != "" {
+		// 只在当前短链路径下携带，避免发送给其他短链
+		secure := c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(accessCookie, result.AccessToken, int(result.AccessTokenTTL.Seconds()), "/"+c.Param("code"), "", secure, true)
+	}
+
+	if result.Teaser {
+		// 链接生效后同一地址将跳转到目标地址
+		c.Header("Cache-Control", "no-store")
+	}
+
+	// 使用 302 临时重定向，便于统计；提交表单后使用 303，让浏览器以 GET 访问目标地址
+	status := http.StatusFound
+	if c.Request.Method == http.MethodPost {
+		status = http.StatusSeeOther
+	}
+	log.Printf("Redirect URL: %s", result.LongURL)
+	c.Redirect(status, result.LongURL)
 }
 
 // 获取客户端IP
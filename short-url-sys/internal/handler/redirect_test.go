@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"short-url-sys/internal/pkg/errors"
+	"short-url-sys/internal/service/link"
+	"short-url-sys/internal/service/redirect"
+
+	"github.com/gin-gonic/gin"
+)
+
+// passwordService 记录收到的密码，密码正确时跳转
+type passwordService struct {
+	redirect.Service
+	password string
+}
+
+func (s *passwordService) Redirect(ctx context.Context, shortCode string, req *redirect.RedirectRequest) (*redirect.RedirectResult, error) {
+	s.password = req.Password
+	if req.Password != "secret" {
+		return nil, errors.ErrPasswordRequired
+	}
+	return &redirect.RedirectResult{LongURL: "https://example.com", ShortCode: shortCode}, nil
+}
+
+func TestRedirect_PasswordOnlyFromHeader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	codeGenerator, err := link.NewShortCodeGenerator(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := &passwordService{}
+	router := gin.New()
+	router.GET("/:code", NewRedirectHandler(service, codeGenerator).Redirect)
+
+	// 查询参数中的密码被忽略
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/abc?password=secret", nil))
+	if service.password != "" || w.Code == http.StatusFound {
+		t.Fatalf("query password used: got %q, status %d", service.password, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/abc", nil)
+	req.Header.Set(passwordHeader, "secret")
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusFound {
+		t.Fatalf("status = %d, want 302", w.Code)
+	}
+}
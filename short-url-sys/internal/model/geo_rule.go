@@ -0,0 +1,78 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MaxGeoRules 每个链接最多的地域规则数量
+const MaxGeoRules = 50
+
+// GeoRule 地域跳转规则，访问者所在国家（及地区）匹配时跳转到 URL
+type GeoRule struct {
+	Country string `json:"country" binding:"required,len=2,alpha"` // ISO 3166-1 alpha-2
+	Region  string `json:"region,omitempty" binding:"omitempty,max=100"`
+	URL     string `json:"url" binding:"required,url"`
+}
+
+// GeoRules 链接的地域跳转规则，以 JSON 保存在 links.geo_rules 列，随链接一起缓存
+type GeoRules []GeoRule
+
+// Scan 实现数据库接口扫描
+func (r *GeoRules) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*r = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported type for GeoRules: %T", value)
+	}
+	if len(data) == 0 {
+		*r = nil
+		return nil
+	}
+	return json.Unmarshal(data, r)
+}
+
+// Value 实现数据库值接口，没有规则时保存为 NULL
+func (r GeoRules) Value() (driver.Value, error) {
+	if len(r) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Match 返回与访问者国家、地区匹配的跳转地址，同时指定国家和地区的规则优先于只指定国家的规则，
+// 同等条件下使用靠前的规则
+func (r GeoRules) Match(country, region string) (string, bool) {
+	if country == "" {
+		return "", false
+	}
+	var fallback string
+	for _, rule := range r {
+		if !strings.EqualFold(rule.Country, country) {
+			continue
+		}
+		if rule.Region == "" {
+			if fallback == "" {
+				fallback = rule.URL
+			}
+			continue
+		}
+		if region != "" && strings.EqualFold(rule.Region, region) {
+			return rule.URL, true
+		}
+	}
+	return fallback, fallback != ""
+}
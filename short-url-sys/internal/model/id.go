@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shgang97/sys-collections/snowflake"
+)
+
+// NextIDResponse 单个ID响应
+type NextIDResponse struct {
+	ID snowflake.ID `json:"id"`
+}
+
+// BatchIDRequest 批量获取ID请求
+type BatchIDRequest struct {
+	Count int `json:"count" binding:"required,min=1"`
+}
+
+// BatchIDResponse 批量ID响应
+type BatchIDResponse struct {
+	IDs []snowflake.ID `json:"ids"`
+}
+
+// DecomposeIDResponse ID解析响应
+type DecomposeIDResponse struct {
+	ID           snowflake.ID `json:"id"`
+	Time         time.Time    `json:"time"`
+	Timestamp    int64        `json:"timestamp"`
+	DatacenterID int64        `json:"datacenter_id"`
+	WorkerID     int64        `json:"worker_id"`
+	Sequence     int64        `json:"sequence"`
+}
+
+// RetryableErrorResponse 暂时无法发号时的响应，retryable 为 true 时客户端可稍后或换一个实例重试
+type RetryableErrorResponse struct {
+	ErrorResponse
+	Retryable bool `json:"retryable"`
+}
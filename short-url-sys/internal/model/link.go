@@ -1,9 +1,13 @@
 package model
 
 import (
+	"crypto/sha256"
 	"database/sql/driver"
+	"encoding/hex"
 	"fmt"
 	"time"
+
+	"github.com/shgang97/sys-collections/snowflake"
 )
 
 type LinkStatus string
@@ -12,8 +16,13 @@ const (
 	LinkStatusActive   LinkStatus = "active"
 	LinkStatusDisabled LinkStatus = "disabled"
 	LinkStatusExpired  LinkStatus = "expired"
+	// LinkStatusExhausted 点击次数达到 max_clicks 上限
+	LinkStatusExhausted LinkStatus = "exhausted"
 )
 
+// ShortCodeMaxLength 短码最大长度，与 links.short_code 列宽一致
+const ShortCodeMaxLength = 16
+
 // 实现数据库接口扫描
 func (ls *LinkStatus) Scan(value interface{}) error {
 	if value == nil {
@@ -39,14 +48,26 @@ func (ls LinkStatus) Value() (driver.Value, error) {
 
 // Link 短链接模型
 type Link struct {
-	ID          uint64     `gorm:"primaryKey;autoIncrement" json:"id"`
-	ShortCode   string     `gorm:"size:10;not null;uniqueIndex" json:"short_code"`
-	LongURL     string     `gorm:"type:text;not null" json:"long_url"`
-	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
-	ClickCount  int64      `gorm:"default:0" json:"click_count"`
+	ID        snowflake.ID `gorm:"primaryKey;autoIncrement" json:"id"` // JSON中以字符串表示，避免前端精度丢失
+	ShortCode string       `gorm:"size:16;not null;uniqueIndex" json:"short_code"`
+	// CaseInsensitive 短码以小写保存，访问时不区分大小写
+	CaseInsensitive bool   `gorm:"default:false" json:"case_insensitive,omitempty"`
+	LongURL         string `gorm:"type:text;not null" json:"long_url"`
+	// LongURLHash 标准化长链接的 SHA-256，long_url 为 TEXT 无法直接建索引，按哈希查找相同目标的链接
+	LongURLHash string `gorm:"size:64;index:idx_long_url_hash_created_by,priority:1" json:"-"`
+	// StartsAt 开始生效时间，之前访问返回尚未生效，为空表示创建后立即生效
+	StartsAt  *time.Time `json:"starts_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// PasswordHash 访问密码的 bcrypt 哈希，为空表示无需密码；不参与 JSON 序列化，缓存时单独保存
+	PasswordHash string `gorm:"size:60" json:"-"`
+	ClickCount   int64  `gorm:"default:0" json:"click_count"`
+	// MaxClicks 允许的最大点击次数，0 表示不限制
+	MaxClicks int64 `gorm:"default:0" json:"max_clicks,omitempty"`
+	// GeoRules 按访问者地域跳转到其他地址的规则，都不匹配时跳转到 LongURL
+	GeoRules    GeoRules   `gorm:"type:json" json:"geo_rules,omitempty"`
 	Status      LinkStatus `gorm:"size:20;default:active" json:"status"`
 	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
-	CreatedBy   string     `gorm:"size:100" json:"created_by,omitempty"`
+	CreatedBy   string     `gorm:"size:100;index:idx_long_url_hash_created_by,priority:2" json:"created_by,omitempty"`
 	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
 	UpdatedBy   string     `gorm:"size:100" json:"updated_by,omitempty"`
 	Description string     `gorm:"size:500" json:"description,omitempty"`
@@ -59,12 +80,32 @@ func (l *Link) TableName() string {
 	return "links"
 }
 
-// IsActive 检查链接是否有效
+// HashLongURL 计算标准化长链接的哈希，用于 long_url_hash 列
+func HashLongURL(longURL string) string {
+	sum := sha256.Sum256([]byte(longURL))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsPasswordProtected 访问链接是否需要密码
+func (l *Link) IsPasswordProtected() bool {
+	return l.PasswordHash != ""
+}
+
+// NotStarted 链接是否尚未到开始生效时间
+func (l *Link) NotStarted() bool {
+	return l.StartsAt != nil && time.Now().Before(*l.StartsAt)
+}
+
+// IsActive 检查链接是否有效，按当前时间判断生效区间，缓存中的链接到达开始时间后自动生效
 func (l *Link) IsActive() bool {
 	if l.Status != LinkStatusActive {
 		return false
 	}
 
+	if l.NotStarted() {
+		return false
+	}
+
 	if l.ExpiresAt != nil && l.ExpiresAt.Before(time.Now()) {
 		return false
 	}
@@ -74,7 +115,7 @@ func (l *Link) IsActive() bool {
 // ClickStats 点击统计模型
 type ClickStats struct {
 	ID          uint64    `gorm:"primaryKey;autoIncrement" json:"id"`
-	ShortCode   string    `gorm:"size:10;not null;index" json:"short_code"`
+	ShortCode   string    `gorm:"size:16;not null;index" json:"short_code"`
 	IPAddress   string    `gorm:"size:45" json:"ip_address"` // 支持IPv6
 	UserAgent   string    `gorm:"type:text" json:"user_agent"`
 	Referer     string    `gorm:"type:text" json:"referer"`
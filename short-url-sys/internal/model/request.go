@@ -5,10 +5,19 @@ import "time"
 // CreateShortRequest 创建短链请求
 type CreateShortRequest struct {
 	LongURL     string     `json:"long_url" binding:"required,url"`
-	CustomCode  *string    `json:"custom_code,omitempty" binding:"omitempty,alphanum,min=3,max=10"` // 使用指针类型，区分“未设置”和“设置”，指针为 nil，表示客户端没有提供该字段
+	CustomCode  *string    `json:"custom_code,omitempty" binding:"omitempty,min=3,max=16"` // 使用指针类型，区分“未设置”和“设置”，指针为 nil，表示客户端没有提供该字段
+	StartsAt    *time.Time `json:"starts_at,omitempty"`                                    // 开始生效时间，未设置时立即生效
 	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
 	Description *string    `json:"description,omitempty" binding:"omitempty,max=500"`
 	CreatedBy   *string    `json:"created_by,omitempty" binding:"omitempty,max=100"`
+	// CaseInsensitive 自定义短码不区分大小写，以小写保存
+	CaseInsensitive *bool `json:"case_insensitive,omitempty"`
+	// ReuseExisting 同一创建者已有相同目标的有效链接时直接返回该链接，未设置时使用服务端默认配置，指定自定义短码时不生效
+	ReuseExisting *bool `json:"reuse_existing,omitempty"`
+	// MaxClicks 允许的最大点击次数，达到后链接失效，未设置表示不限制
+	MaxClicks *int64 `json:"max_clicks,omitempty" binding:"omitempty,min=1"`
+	// Password 访问密码，设置后访问短链需要先输入密码
+	Password *string `json:"password,omitempty" binding:"omitempty,min=4,max=72"`
 }
 
 // BatchCreateRequest 批量创建短链请求
@@ -17,16 +26,31 @@ type BatchCreateRequest struct {
 }
 
 type BatchURLItem struct {
-	LongURL    string  `json:"long_url" binding:"required,url"`
-	CustomCode *string `json:"custom_code,omitempty" binding:"omitempty,alphanum,min=3,max=10"`
+	LongURL         string  `json:"long_url" binding:"required,url"`
+	CustomCode      *string `json:"custom_code,omitempty" binding:"omitempty,min=3,max=16"`
+	CaseInsensitive *bool   `json:"case_insensitive,omitempty"`
 }
 
 // UpdateLinkRequest 更新链接请求
 type UpdateLinkRequest struct {
 	LongURL     *string    `json:"long_url" binding:"required,url" binding:"omitempty,url"` // 使用指针类型，区分“未设置”和“设置”
+	StartsAt    *time.Time `json:"starts_at,omitempty"`
 	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
 	Status      *string    `json:"status,omitempty" binding:"omitempty,oneof=active disabled"`
 	Description *string    `json:"description,omitempty" binding:"omitempty,max=500"`
+	MaxClicks   *int64     `json:"max_clicks,omitempty" binding:"omitempty,min=0"` // 0 表示取消限制
+	Password    *string    `json:"password,omitempty" binding:"omitempty,max=72"`  // 空字符串表示取消密码
+}
+
+// GeoRulesRequest 替换链接的全部地域跳转规则
+type GeoRulesRequest struct {
+	Rules []GeoRule `json:"rules" binding:"max=50,dive"` // 最多 MaxGeoRules 条，空列表表示清空规则
+}
+
+// DeleteGeoRuleRequest 删除地域跳转规则，未指定国家时删除全部规则
+type DeleteGeoRuleRequest struct {
+	Country string `form:"country" binding:"omitempty,len=2,alpha"`
+	Region  string `form:"region" binding:"omitempty,max=100"`
 }
 
 type DeleteLinkRequest struct {
@@ -34,12 +58,18 @@ type DeleteLinkRequest struct {
 	UpdatedBy *string `json:"updated_by,omitempty" binding:"omitempty,max=100"`
 }
 
+// AvailabilityRequest 短码可用性检查的查询参数
+type AvailabilityRequest struct {
+	// CaseInsensitive 与创建请求的 case_insensitive 相同，任意大小写形式被占用都视为不可用
+	CaseInsensitive bool `form:"case_insensitive"`
+}
+
 // ListLinksRequest 列表查询请求
 type ListLinksRequest struct {
 	Page      int     `form:"page,default=1" binding:"omitempty,min=1"`
 	PageSize  int     `form:"page_size,default=10" binding:"omitempty,min=1,max=100"`
 	CreatedBy *string `form:"created_by,omitempty" binding:"omitempty,max=100"`
-	Status    *string `form:"status,omitempty" binding:"omitempty,oneof=active disabled expired"`
+	Status    *string `form:"status,omitempty" binding:"omitempty,oneof=active disabled expired exhausted"`
 }
 
 // 统计请求查询
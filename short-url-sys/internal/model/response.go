@@ -18,11 +18,21 @@ type LinkInfoResponse struct {
 	LongURL      string     `json:"long_url"`
 	CreatedAt    time.Time  `json:"created_at"`
 	UpdatedAt    time.Time  `json:"updated_at"`
+	StartsAt     *time.Time `json:"starts_at,omitempty"`  // nil值创建后立即生效
 	ExpiresAt    *time.Time `json:"expires_at,omitempty"` // nil值永不过期
 	ClickCount   int64      `json:"click_count"`
+	MaxClicks    int64      `json:"max_clicks,omitempty"`    // 0 值不限制
 	LastAccessed *time.Time `json:"last_accessed,omitempty"` // nil值从未被访问
 	Status       string     `json:"status"`
 	Description  string     `json:"description,omitempty"`
+	// PasswordProtected 访问链接是否需要密码
+	PasswordProtected bool `json:"password_protected,omitempty"`
+}
+
+// GeoRulesResponse 链接的地域跳转规则
+type GeoRulesResponse struct {
+	ShortCode string    `json:"short_code"`
+	Rules     []GeoRule `json:"rules"`
 }
 
 // BatchCreateResponse 批量创建响应
@@ -56,6 +66,7 @@ type HealthResponse struct {
 	Status    string            `json:"status"`
 	Timestamp int64             `json:"timestamp"`
 	Services  map[string]string `json:"services,omitempty"`
+	Metrics   map[string]int64  `json:"metrics,omitempty"`
 }
 
 type ErrorResponse struct {
@@ -64,6 +75,21 @@ type ErrorResponse struct {
 	Message string `json:"message,omitempty"`
 }
 
+// ShortCodeConflictResponse 自定义短码已被占用时的响应
+type ShortCodeConflictResponse struct {
+	ErrorResponse
+	Suggestions []string `json:"suggestions"` // 可用的替代自定义短码
+}
+
+// AvailabilityResponse 短码可用性检查响应
+type AvailabilityResponse struct {
+	Code        string   `json:"code"`
+	ShortCode   string   `json:"short_code,omitempty"` // 实际使用的短码，启用校验字符时末尾追加校验字符
+	Available   bool     `json:"available"`
+	Reason      string   `json:"reason,omitempty"`
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
 type StatsResponse struct {
 	ShortCode   string           `json:"short_code"`
 	TotalClicks int64            `json:"total_clicks"`
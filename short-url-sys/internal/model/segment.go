@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// IDSegment ID号段，每次分配将 max_id 增加 step
+type IDSegment struct {
+	BizTag      string    `gorm:"primaryKey;size:64" json:"biz_tag"`
+	MaxID       uint64    `gorm:"not null;default:0" json:"max_id"`
+	Step        uint64    `gorm:"not null;default:1000" json:"step"`
+	Description string    `gorm:"size:256" json:"description,omitempty"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 指定表名
+func (s *IDSegment) TableName() string {
+	return "id_segments"
+}
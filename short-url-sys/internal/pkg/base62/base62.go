@@ -1,46 +1,174 @@
 package base62
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"strings"
 )
 
 const (
-	base         = 62
 	characterSet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+	// NoLookalikeCharacterSet 去掉易混淆字符 0/O/1/I/l 的字母表，共 57 个字符
+	NoLookalikeCharacterSet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+	// Base36CharacterSet 数字加小写字母，适用于不区分大小写的短码
+	Base36CharacterSet = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+	// NoLookalikeLowerCharacterSet 去掉易混淆字符 0/1/l/o 的小写字母表，共 32 个字符
+	NoLookalikeLowerCharacterSet = "23456789abcdefghijkmnpqrstuvwxyz"
 )
 
-// Encode 将数字编码为Base62字符串
-func Encode(num uint64) string {
+var ErrInvalidAlphabet = errors.New("alphabet must contain at least 2 unique ASCII characters")
+
+// StdEncoding 标准Base62编码
+var StdEncoding = MustNewEncoding(characterSet)
+
+// Encoding 基于任意字母表的进制编码，进制为字母表长度，alphabet[0] 表示 0
+type Encoding struct {
+	alphabet string
+	base     uint64
+	decode   [256]int16
+}
+
+// NewEncoding 创建编码，alphabet 中的字符必须为 ASCII 且互不重复
+func NewEncoding(alphabet string) (*Encoding, error) {
+	if len(alphabet) < 2 {
+		return nil, ErrInvalidAlphabet
+	}
+	e := &Encoding{
+		alphabet: alphabet,
+		base:     uint64(len(alphabet)),
+	}
+	for i := range e.decode {
+		e.decode[i] = -1
+	}
+	for i := 0; i < len(alphabet); i++ {
+		c := alphabet[i]
+		if c >= 0x80 || e.decode[c] != -1 {
+			return nil, fmt.Errorf("%w: invalid or duplicate character '%c'", ErrInvalidAlphabet, c)
+		}
+		e.decode[c] = int16(i)
+	}
+	return e, nil
+}
+
+// MustNewEncoding 同 NewEncoding，字母表不合法时 panic
+func MustNewEncoding(alphabet string) *Encoding {
+	e, err := NewEncoding(alphabet)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// Alphabet 返回字母表
+func (e *Encoding) Alphabet() string {
+	return e.alphabet
+}
+
+// HasUpper 字母表是否包含大写字母，不包含时编码结果转为小写后不变
+func (e *Encoding) HasUpper() bool {
+	return strings.ToLower(e.alphabet) != e.alphabet
+}
+
+// Encode 将数字编码为字符串
+func (e *Encoding) Encode(num uint64) string {
 	if num == 0 {
-		return "0"
+		return e.alphabet[:1]
 	}
 
-	var encoded []byte
+	var buf [64]byte
+	i := len(buf)
 	for num > 0 {
-		remainder := num % base
-		num /= base
-		encoded = append([]byte{characterSet[remainder]}, encoded...)
+		i--
+		buf[i] = e.alphabet[num%e.base]
+		num /= e.base
 	}
+	return string(buf[i:])
+}
 
-	return string(encoded)
+// EncodePadded 将数字编码为字符串并在左侧用零字符填充到 length，解码结果不变
+func (e *Encoding) EncodePadded(num uint64, length int) string {
+	return PadLeft(e.Encode(num), length, e.alphabet[0])
 }
 
-// Decode 将数字编码为Base62字符串
-func Decode(encoded string) (uint64, error) {
+// Decode 将字符串解码为数字
+func (e *Encoding) Decode(encoded string) (uint64, error) {
 	var num uint64
-	length := len(encoded)
-	for i, char := range encoded {
-		pos := strings.IndexRune(characterSet, char)
+	for i := 0; i < len(encoded); i++ {
+		pos := e.decode[encoded[i]]
 		if pos == -1 {
-			return 0, fmt.Errorf("invalid character '%c'", char)
+			return 0, fmt.Errorf("invalid character '%c'", encoded[i])
+		}
+		if num > (math.MaxUint64-uint64(pos))/e.base {
+			return 0, fmt.Errorf("value of %q overflows uint64", encoded)
 		}
-		num += uint64(pos) * uint64(math.Pow(float64(base), float64(length-i-1)))
+		num = num*e.base + uint64(pos)
 	}
 	return num, nil
 }
 
+// EncodedLen 返回 num 编码后的长度
+func (e *Encoding) EncodedLen(num uint64) int {
+	n := 1
+	for num >= e.base {
+		num /= e.base
+		n++
+	}
+	return n
+}
+
+// CheckChar 按 Luhn mod N 算法计算 s 的校验字符，可检测任意单字符错误和大部分相邻字符换位
+func (e *Encoding) CheckChar(s string) (byte, error) {
+	sum, err := e.luhnSum(s, 2)
+	if err != nil {
+		return 0, err
+	}
+	return e.alphabet[(e.base-sum%e.base)%e.base], nil
+}
+
+// ValidCheckChar 校验 s 的最后一个字符是否为前面部分的校验字符
+func (e *Encoding) ValidCheckChar(s string) bool {
+	if len(s) < 2 {
+		return false
+	}
+	sum, err := e.luhnSum(s, 1)
+	return err == nil && sum%e.base == 0
+}
+
+// luhnSum 从右向左累加，factor 为最右侧字符的权重，权重在 1 和 2 之间交替。
+// 偶数进制按 Luhn mod N 对加倍后的值做各位求和；奇数进制下该映射不再是一一映射，
+// 改为对加倍后的值取模（2 与奇数互素，取模后仍是一一映射）
+func (e *Encoding) luhnSum(s string, factor uint64) (uint64, error) {
+	var sum uint64
+	for i := len(s) - 1; i >= 0; i-- {
+		pos := e.decode[s[i]]
+		if pos == -1 {
+			return 0, fmt.Errorf("invalid character '%c'", s[i])
+		}
+		addend := factor * uint64(pos)
+		if e.base%2 == 0 {
+			sum += addend/e.base + addend%e.base
+		} else {
+			sum += addend % e.base
+		}
+		factor = 3 - factor
+	}
+	return sum, nil
+}
+
+// Encode 将数字编码为Base62字符串
+func Encode(num uint64) string {
+	return StdEncoding.Encode(num)
+}
+
+// Decode 将Base62字符串解码为数字
+func Decode(encoded string) (uint64, error) {
+	return StdEncoding.Decode(encoded)
+}
+
 // PadLeft 在字符串左侧填充字符到指定长度
 func PadLeft(str string, length int, pacChar byte) string {
 	if len(str) >= length {
@@ -0,0 +1,127 @@
+package base62
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+var testAlphabets = map[string]string{
+	"base62":             characterSet,
+	"no_lookalike":       NoLookalikeCharacterSet,
+	"base36":             Base36CharacterSet,
+	"no_lookalike_lower": NoLookalikeLowerCharacterSet,
+	"custom odd":         "abcdefg",
+	"custom even":        "xyz123",
+}
+
+func TestEncoding_RoundTrip(t *testing.T) {
+	for name, alphabet := range testAlphabets {
+		e := MustNewEncoding(alphabet)
+		for _, num := range []uint64{0, 1, e.base - 1, e.base, 1 << 40, 361368585391575048, math.MaxUint64} {
+			s := e.Encode(num)
+			if len(s) != e.EncodedLen(num) {
+				t.Errorf("%s: len(Encode(%d)) = %d, EncodedLen = %d", name, num, len(s), e.EncodedLen(num))
+			}
+			got, err := e.Decode(s)
+			if err != nil || got != num {
+				t.Errorf("%s: Decode(%q) = %d, %v, want %d", name, s, got, err, num)
+			}
+
+			// 填充零字符后解码结果不变
+			padded := e.EncodePadded(num, 16)
+			if len(padded) < 16 || padded[len(padded)-len(s):] != s {
+				t.Errorf("%s: EncodePadded(%d, 16) = %q", name, num, padded)
+			}
+			got, err = e.Decode(padded)
+			if err != nil || got != num {
+				t.Errorf("%s: Decode(%q) = %d, %v, want %d", name, padded, got, err, num)
+			}
+		}
+	}
+}
+
+func TestEncoding_DecodeRejects(t *testing.T) {
+	e := MustNewEncoding(Base36CharacterSet)
+	for _, s := range []string{"A", "ab-c", "zzzzzzzzzzzzzzzzzzzz"} {
+		if _, err := e.Decode(s); err == nil {
+			t.Errorf("Decode(%q) succeeded", s)
+		}
+	}
+}
+
+func TestNewEncoding_Rejects(t *testing.T) {
+	for _, alphabet := range []string{"", "a", "abca", "ab\x80"} {
+		if _, err := NewEncoding(alphabet); !errors.Is(err, ErrInvalidAlphabet) {
+			t.Errorf("NewEncoding(%q) err = %v, want ErrInvalidAlphabet", alphabet, err)
+		}
+	}
+}
+
+func withCheckChar(t *testing.T, e *Encoding, s string) string {
+	t.Helper()
+	c, err := e.CheckChar(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s + string(c)
+}
+
+func TestCheckChar_DetectsSingleCharacterErrors(t *testing.T) {
+	for name, alphabet := range testAlphabets {
+		e := MustNewEncoding(alphabet)
+		code := withCheckChar(t, e, e.EncodePadded(123456, 6))
+		if !e.ValidCheckChar(code) {
+			t.Fatalf("%s: ValidCheckChar(%q) = false", name, code)
+		}
+		// 任意位置（包括校验字符本身）替换为任意其他字符都能检出
+		for i := 0; i < len(code); i++ {
+			for j := 0; j < len(alphabet); j++ {
+				if alphabet[j] == code[i] {
+					continue
+				}
+				typo := code[:i] + string(alphabet[j]) + code[i+1:]
+				if e.ValidCheckChar(typo) {
+					t.Errorf("%s: typo %q of %q passes", name, typo, code)
+				}
+			}
+		}
+	}
+}
+
+func TestCheckChar_DetectsAdjacentTranspositions(t *testing.T) {
+	for name, alphabet := range testAlphabets {
+		e := MustNewEncoding(alphabet)
+		for a := 0; a < len(alphabet); a++ {
+			for b := 0; b < len(alphabet); b++ {
+				if a == b {
+					continue
+				}
+				for _, prefix := range []string{"", alphabet[1:2]} {
+					code := withCheckChar(t, e, prefix+string(alphabet[a])+string(alphabet[b]))
+					n := len(prefix)
+					swapped := code[:n] + code[n+1:n+2] + code[n:n+1] + code[n+2:]
+					if !e.ValidCheckChar(swapped) {
+						continue
+					}
+					// Luhn mod N 在偶数进制下唯一漏检的是首尾两个字符互换，奇数进制下全部能检出
+					if e.base%2 == 1 || !(a == 0 && b == len(alphabet)-1 || b == 0 && a == len(alphabet)-1) {
+						t.Errorf("%s: transposition %q of %q passes", name, swapped, code)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestCheckChar_InvalidInput(t *testing.T) {
+	e := MustNewEncoding(Base36CharacterSet)
+	if _, err := e.CheckChar("aB"); err == nil {
+		t.Error("CheckChar accepted a character outside the alphabet")
+	}
+	for _, s := range []string{"", "a", "aB"} {
+		if e.ValidCheckChar(s) {
+			t.Errorf("ValidCheckChar(%q) = true", s)
+		}
+	}
+}
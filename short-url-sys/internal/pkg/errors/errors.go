@@ -4,12 +4,18 @@ import "fmt"
 
 // 定义错误类型
 var (
-	ErrLinkNotFound     = NewBusinessError("link not found")
-	ErrLinkExpired      = NewBusinessError("link expired")
-	ErrLinkDisabled     = NewBusinessError("link disabled")
-	ErrInvalidURL       = NewBusinessError("invalid URL")
-	ErrShortCodeExists  = NewBusinessError("short code already exists")
-	ErrInvalidShortCode = NewBusinessError("invalid short code")
+	ErrLinkNotFound        = NewBusinessError("link not found")
+	ErrLinkExpired         = NewBusinessError("link expired")
+	ErrLinkDisabled        = NewBusinessError("link disabled")
+	ErrLinkExhausted       = NewBusinessError("link reached its click limit")
+	ErrLinkNotStarted      = NewBusinessError("link not yet available")
+	ErrInvalidURL          = NewBusinessError("invalid URL")
+	ErrShortCodeExists     = NewBusinessError("short code already exists")
+	ErrInvalidShortCode    = NewBusinessError("invalid short code")
+	ErrShortCodeNotAllowed = NewBusinessError("short code is reserved or not allowed")
+	ErrPasswordRequired    = NewBusinessError("password required")
+	ErrPasswordInvalid     = NewBusinessError("invalid password")
+	ErrTooManyAttempts     = NewBusinessError("too many failed password attempts")
 )
 
 type BusinessError struct {
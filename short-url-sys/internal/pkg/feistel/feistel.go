@@ -0,0 +1,83 @@
+package feistel
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const defaultRounds = 4
+
+var (
+	ErrInvalidKey  = errors.New("feistel key must not be empty")
+	ErrInvalidBits = errors.New("feistel bits must be an even number between 2 and 64")
+)
+
+// Cipher 基于 HMAC-SHA256 轮函数的平衡 Feistel 置换，
+// 对低 bits 位做可逆的一一映射，高于 bits 的位保持不变，因此整个 uint64 空间上不会产生冲突
+type Cipher struct {
+	key      []byte
+	rounds   int
+	half     uint
+	mask     uint64
+	halfMask uint64
+}
+
+// New 创建 Feistel 置换，bits 为参与置换的位数（必须为偶数），rounds 不大于 0 时使用默认轮数
+func New(key []byte, bits, rounds int) (*Cipher, error) {
+	if len(key) == 0 {
+		return nil, ErrInvalidKey
+	}
+	if bits < 2 || bits > 64 || bits%2 != 0 {
+		return nil, fmt.Errorf("%w: got %d", ErrInvalidBits, bits)
+	}
+	if rounds <= 0 {
+		rounds = defaultRounds
+	}
+	half := uint(bits / 2)
+	return &Cipher{
+		key:      key,
+		rounds:   rounds,
+		half:     half,
+		mask:     ^uint64(0) >> (64 - uint(bits)),
+		halfMask: ^uint64(0) >> (64 - half),
+	}, nil
+}
+
+// Mask 返回参与置换的位掩码
+func (c *Cipher) Mask() uint64 {
+	return c.mask
+}
+
+// Encrypt 置换 x
+func (c *Cipher) Encrypt(x uint64) uint64 {
+	l, r := (x&c.mask)>>c.half, x&c.halfMask
+	for i := 0; i < c.rounds; i++ {
+		l, r = r, l^c.round(i, r)
+	}
+	return c.join(x, l, r)
+}
+
+// Decrypt 还原 Encrypt 的结果
+func (c *Cipher) Decrypt(x uint64) uint64 {
+	l, r := (x&c.mask)>>c.half, x&c.halfMask
+	for i := c.rounds - 1; i >= 0; i-- {
+		l, r = r^c.round(i, l), l
+	}
+	return c.join(x, l, r)
+}
+
+func (c *Cipher) join(x, l, r uint64) uint64 {
+	return x&^c.mask | l<<c.half | r
+}
+
+func (c *Cipher) round(i int, r uint64) uint64 {
+	var buf [9]byte
+	buf[0] = byte(i)
+	binary.BigEndian.PutUint64(buf[1:], r)
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write(buf[:])
+	return binary.BigEndian.Uint64(mac.Sum(nil)) & c.halfMask
+}
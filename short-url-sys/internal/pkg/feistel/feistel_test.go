@@ -0,0 +1,85 @@
+package feistel
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+var testKey = []byte("test-key")
+
+func TestCipher_BijectionSmallWidths(t *testing.T) {
+	// 小位宽下穷举整个取值空间，置换结果互不相同且都能还原
+	for _, bits := range []int{2, 4, 8, 16} {
+		c, err := New(testKey, bits, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		size := uint64(1) << bits
+		seen := make(map[uint64]bool, size)
+		for x := uint64(0); x < size; x++ {
+			y := c.Encrypt(x)
+			if y >= size {
+				t.Fatalf("bits %d: Encrypt(%d) = %d out of range", bits, x, y)
+			}
+			if seen[y] {
+				t.Fatalf("bits %d: Encrypt(%d) = %d collides", bits, x, y)
+			}
+			seen[y] = true
+			if got := c.Decrypt(y); got != x {
+				t.Fatalf("bits %d: Decrypt(Encrypt(%d)) = %d", bits, x, got)
+			}
+		}
+	}
+}
+
+func TestCipher_RoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, bits := range []int{32, 40, 48, 62, 64} {
+		for _, rounds := range []int{0, 3, 8} {
+			c, err := New(testKey, bits, rounds)
+			if err != nil {
+				t.Fatal(err)
+			}
+			values := []uint64{0, 1, c.Mask(), c.Mask() + 1, ^uint64(0)}
+			for i := 0; i < 1000; i++ {
+				values = append(values, rng.Uint64())
+			}
+			for _, x := range values {
+				y := c.Encrypt(x)
+				if got := c.Decrypt(y); got != x {
+					t.Fatalf("bits %d rounds %d: Decrypt(Encrypt(%d)) = %d", bits, rounds, x, got)
+				}
+				// 高于 bits 的位保持不变
+				if y&^c.Mask() != x&^c.Mask() {
+					t.Fatalf("bits %d: Encrypt(%#x) = %#x changed the high bits", bits, x, y)
+				}
+			}
+		}
+	}
+}
+
+func TestCipher_KeyChangesPermutation(t *testing.T) {
+	a, _ := New([]byte("a"), 40, 0)
+	b, _ := New([]byte("b"), 40, 0)
+	same := 0
+	for x := uint64(0); x < 100; x++ {
+		if a.Encrypt(x) == b.Encrypt(x) {
+			same++
+		}
+	}
+	if same > 1 {
+		t.Fatalf("%d of 100 values map the same under different keys", same)
+	}
+}
+
+func TestNew_Rejects(t *testing.T) {
+	if _, err := New(nil, 40, 0); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("empty key err = %v, want ErrInvalidKey", err)
+	}
+	for _, bits := range []int{0, 3, 66} {
+		if _, err := New(testKey, bits, 0); !errors.Is(err, ErrInvalidBits) {
+			t.Errorf("bits %d err = %v, want ErrInvalidBits", bits, err)
+		}
+	}
+}
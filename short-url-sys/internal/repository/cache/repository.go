@@ -2,62 +2,129 @@ package cache
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"short-url-sys/internal/config"
+	"short-url-sys/internal/model"
 	"short-url-sys/internal/pkg/errors"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
+// consumeClickScript 原子地检查并消耗一次点击额度，计数不存在时以 ARGV[1]（数据库中的点击数）初始化，
+// ARGV[1] 小于 0 表示调用方尚未读取数据库，计数不存在时返回 -2；
+// ARGV[3] 大于 0 时计数在该毫秒时间戳过期，额度已用完返回 -1，否则返回消耗后的已用次数
+var consumeClickScript = redis.NewScript(`
+local used = redis.call('GET', KEYS[1])
+if not used then
+	if tonumber(ARGV[1]) < 0 then
+		return -2
+	end
+	used = ARGV[1]
+	redis.call('SET', KEYS[1], used)
+	if tonumber(ARGV[3]) > 0 then
+		redis.call('PEXPIREAT', KEYS[1], ARGV[3])
+	end
+end
+if tonumber(used) >= tonumber(ARGV[2]) then
+	return -1
+end
+return redis.call('INCR', KEYS[1])
+`)
+
+// reservePasswordAttemptScript 原子地占用一次密码尝试，计数包含失败和正在校验的尝试，
+// 窗口从第一次尝试开始计算，超过 ARGV[1] 次时撤回本次占用并返回 -1
+var reservePasswordAttemptScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+if count > tonumber(ARGV[1]) then
+	redis.call('DECR', KEYS[1])
+	return -1
+end
+return count
+`)
+
+// releasePasswordAttemptScript 撤回一次占用，计数已过期时不做处理，避免生成没有过期时间的负数计数
+var releasePasswordAttemptScript = redis.NewScript(`
+local count = tonumber(redis.call('GET', KEYS[1]))
+if count and count > 0 then
+	return redis.call('DECR', KEYS[1])
+end
+return 0
+`)
+
+// cachedLink 缓存中的链接，Link 的 JSON 不包含密码哈希，重定向校验密码需要它，因此单独保存
+type cachedLink struct {
+	*model.Link
+	PasswordHash string `json:"password_hash,omitempty"`
+}
+
 type Repository struct {
-	client *redis.Client
-	prefix string
-	ttl    time.Duration
+	client          *redis.Client
+	prefix          string
+	ttl             time.Duration
+	caseInsensitive bool
 }
 
-func NewRepository(client *redis.Client, cfg *config.CacheConfig) *Repository {
+// NewRepository 创建缓存仓库，caseInsensitive 为 true 时短码在生成缓存键前统一转为小写
+func NewRepository(client *redis.Client, cfg *config.CacheConfig, caseInsensitive bool) *Repository {
 	return &Repository{
-		client: client,
-		prefix: cfg.Prefix,
-		ttl:    time.Duration(cfg.TTL) * time.Second,
+		client:          client,
+		prefix:          cfg.Prefix,
+		ttl:             time.Duration(cfg.TTL) * time.Second,
+		caseInsensitive: caseInsensitive,
 	}
 }
 
-func (r *Repository) getKey(typ string, id string) string {
-	return fmt.Sprintf("%s:%s:%s", r.prefix, typ, id)
+func (r *Repository) getKey(typ string, shortCode string) string {
+	if r.caseInsensitive {
+		shortCode = strings.ToLower(shortCode)
+	}
+	return fmt.Sprintf("%s:%s:%s", r.prefix, typ, shortCode)
 }
 
-// GetShortURL 从缓存获取短链映射
-func (r *Repository) GetShortURL(ctx context.Context, shortCode string) (string, error) {
-	key := r.getKey("url", shortCode)
-	result, err := r.client.Get(ctx, key).Result()
+// GetLink 从缓存获取短链
+func (r *Repository) GetLink(ctx context.Context, shortCode string) (*model.Link, error) {
+	key := r.getKey("link", shortCode)
+	result, err := r.client.Get(ctx, key).Bytes()
 	if err != nil {
 		if err == redis.Nil {
-			return "", errors.ErrLinkNotFound
+			return nil, errors.ErrLinkNotFound
 		}
-		return "", &errors.RepositoryError{Operation: "GetShortURL", Err: err}
+		return nil, &errors.RepositoryError{Operation: "GetLink", Err: err}
 	}
-	return result, nil
+	cached := cachedLink{Link: &model.Link{}}
+	if err := json.Unmarshal(result, &cached); err != nil {
+		return nil, &errors.RepositoryError{Operation: "GetLink", Err: err}
+	}
+	cached.Link.PasswordHash = cached.PasswordHash
+	return cached.Link, nil
 }
 
-// SetShortURL 设置短链映射到缓存
-func (r *Repository) SetShortURL(ctx context.Context, shortCode string, longURL string) error {
-	key := r.getKey("url", shortCode)
-	err := r.client.Set(ctx, key, longURL, r.ttl).Err()
+// SetLink 设置短链到缓存，以链接实际的短码为键
+func (r *Repository) SetLink(ctx context.Context, link *model.Link) error {
+	data, err := json.Marshal(cachedLink{Link: link, PasswordHash: link.PasswordHash})
 	if err != nil {
-		return &errors.RepositoryError{Operation: "SetShortURL", Err: err}
+		return &errors.RepositoryError{Operation: "SetLink", Err: err}
+	}
+	key := r.getKey("link", link.ShortCode)
+	if err := r.client.Set(ctx, key, data, r.ttl).Err(); err != nil {
+		return &errors.RepositoryError{Operation: "SetLink", Err: err}
 	}
 	return nil
 }
 
-// DeleteShortURL 删除缓存中的短链接
-func (r *Repository) DeleteShortURL(ctx context.Context, shortCode string) error {
-	key := r.getKey("url", shortCode)
+// DeleteLink 删除缓存中的短链
+func (r *Repository) DeleteLink(ctx context.Context, shortCode string) error {
+	key := r.getKey("link", shortCode)
 
 	err := r.client.Del(ctx, key).Err()
 	if err != nil {
-		return &errors.RepositoryError{Operation: "DeleteShortURL", Err: err}
+		return &errors.RepositoryError{Operation: "DeleteLink", Err: err}
 	}
 	return nil
 }
@@ -77,6 +144,65 @@ func (r *Repository) IncrementClickCount(ctx context.Context, shortCode string)
 	return count, nil
 }
 
+// ConsumeClick 消耗一次点击额度，额度已用完时返回 -1。额度计数不存在时才调用 loadClicks 读取已用的次数，
+// 应从数据库读取，缓存中链接的点击数可能已经滞后。
+// 额度计数只在链接过期时失效，避免提前过期后以滞后的数据库点击数重新初始化导致多放行，
+// 链接用完或删除时由 DeleteClickQuota 清除
+func (r *Repository) ConsumeClick(ctx context.Context, shortCode string, loadClicks func(context.Context) (int64, error), maxClicks int64, expiresAt *time.Time) (int64, error) {
+	key := r.getKey("click_quota", shortCode)
+	var expireAt int64
+	if expiresAt != nil {
+		expireAt = expiresAt.UnixMilli()
+	}
+	used, err := consumeClickScript.Run(ctx, r.client, []string{key}, -1, maxClicks, expireAt).Int64()
+	if err != nil {
+		return 0, &errors.RepositoryError{Operation: "ConsumeClick", Err: err}
+	}
+	if used != -2 {
+		return used, nil
+	}
+
+	// 并发初始化时只有第一个写入生效，其余请求在脚本中读到已有计数
+	initial, err := loadClicks(ctx)
+	if err != nil {
+		return 0, err
+	}
+	used, err = consumeClickScript.Run(ctx, r.client, []string{key}, initial, maxClicks, expireAt).Int64()
+	if err != nil {
+		return 0, &errors.RepositoryError{Operation: "ConsumeClick", Err: err}
+	}
+	return used, nil
+}
+
+// DeleteClickQuota 删除短码的点击额度计数
+func (r *Repository) DeleteClickQuota(ctx context.Context, shortCode string) error {
+	key := r.getKey("click_quota", shortCode)
+	if err := r.client.Del(ctx, key).Err(); err != nil {
+		return &errors.RepositoryError{Operation: "DeleteClickQuota", Err: err}
+	}
+	return nil
+}
+
+// ReservePasswordAttempt 在校验密码之前占用一次尝试，window 内失败和正在校验的尝试达到 maxAttempts 次时返回 false；
+// 先占用再校验，并发提交的错误密码也不会超过次数限制。密码正确时调用 ReleasePasswordAttempt 撤回占用
+func (r *Repository) ReservePasswordAttempt(ctx context.Context, shortCode string, maxAttempts int, window time.Duration) (bool, error) {
+	key := r.getKey("password_failures", shortCode)
+	count, err := reservePasswordAttemptScript.Run(ctx, r.client, []string{key}, maxAttempts, window.Milliseconds()).Int64()
+	if err != nil {
+		return false, &errors.RepositoryError{Operation: "ReservePasswordAttempt", Err: err}
+	}
+	return count > 0, nil
+}
+
+// ReleasePasswordAttempt 撤回 ReservePasswordAttempt 占用的一次尝试
+func (r *Repository) ReleasePasswordAttempt(ctx context.Context, shortCode string) error {
+	key := r.getKey("password_failures", shortCode)
+	if err := releasePasswordAttemptScript.Run(ctx, r.client, []string{key}).Err(); err != nil {
+		return &errors.RepositoryError{Operation: "ReleasePasswordAttempt", Err: err}
+	}
+	return nil
+}
+
 func (r *Repository) GetClickCount(ctx context.Context, shortCode string) (int64, error) {
 	key := r.getKey("clicks", shortCode)
 	count, err := r.client.Get(ctx, key).Int64()
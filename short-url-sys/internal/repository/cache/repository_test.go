@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"short-url-sys/internal/config"
+	"short-url-sys/internal/model"
+	"short-url-sys/internal/testutil"
+)
+
+func newTestRepository(t *testing.T) *Repository {
+	t.Helper()
+	_, client := testutil.NewRedis(t)
+	return NewRepository(client, &config.CacheConfig{TTL: 60, Prefix: "test"}, false)
+}
+
+func TestLinkPasswordHashNotInJSON(t *testing.T) {
+	data, err := json.Marshal(&model.Link{ShortCode: "abc", PasswordHash: "$2a$10$secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret") {
+		t.Fatalf("link JSON leaks the password hash: %s", data)
+	}
+}
+
+func TestSetLinkKeepsPasswordHash(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+	link := &model.Link{ShortCode: "abc", LongURL: "https://example.com", PasswordHash: "$2a$10$secret"}
+
+	if err := repo.SetLink(ctx, link); err != nil {
+		t.Fatal(err)
+	}
+	got, err := repo.GetLink(ctx, "abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.PasswordHash != link.PasswordHash || got.LongURL != link.LongURL {
+		t.Fatalf("got %+v, want password hash and long URL of %+v", got, link)
+	}
+}
+
+func TestConsumeClickSeedsFromLoader(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+	loads := 0
+	// 数据库中已点击 4 次，缓存中的链接可能仍是 0
+	load := func(context.Context) (int64, error) {
+		loads++
+		return 4, nil
+	}
+
+	used, err := repo.ConsumeClick(ctx, "abc", load, 5, nil)
+	if err != nil || used != 5 {
+		t.Fatalf("ConsumeClick = %d, %v, want 5", used, err)
+	}
+	used, err = repo.ConsumeClick(ctx, "abc", load, 5, nil)
+	if err != nil || used != -1 {
+		t.Fatalf("ConsumeClick = %d, %v, want -1", used, err)
+	}
+	if loads != 1 {
+		t.Fatalf("loaded %d times, want only when the quota is missing", loads)
+	}
+}
@@ -37,9 +37,19 @@ func (r *MySQLRepository) FindByShortCode(ctx context.Context, shortCode string)
 	return &link, nil
 }
 
-func (r *MySQLRepository) FindByLongURL(ctx context.Context, longURL string) (*model.Link, error) {
+// FindByLongURL 查询创建者已有的指向相同目标的有效链接，按哈希走索引后再比较原文排除哈希碰撞，
+// 只复用不限过期时间和点击次数的链接，避免新请求拿到一个很快失效的链接
+func (r *MySQLRepository) FindByLongURL(ctx context.Context, longURL, createdBy string) (*model.Link, error) {
 	var link model.Link
-	result := r.db.WithContext(ctx).Where("long_url=?", longURL).First(&link)
+	result := r.db.WithContext(ctx).
+		Where("long_url_hash=? AND created_by=? AND long_url=?", model.HashLongURL(longURL), createdBy, longURL).
+		Where("status=? AND delete_flag=? AND password_hash=?", model.LinkStatusActive, "N", "").
+		Where("starts_at IS NULL OR starts_at<=?", time.Now()).
+		Where("expires_at IS NULL AND max_clicks=?", 0).
+		// 设置了地域跳转规则的链接按访问者跳转到不同地址，不能当作普通链接复用
+		Where("geo_rules IS NULL OR JSON_LENGTH(geo_rules)=0").
+		Order("id DESC").
+		First(&link)
 	if result.Error != nil {
 		if result.Error.Error() == gorm.ErrRecordNotFound.Error() {
 			return nil, errors.ErrLinkNotFound
@@ -49,17 +59,77 @@ func (r *MySQLRepository) FindByLongURL(ctx context.Context, longURL string) (*m
 	return &link, nil
 }
 
+func (r *MySQLRepository) FindCaseInsensitive(ctx context.Context, shortCode string) (*model.Link, error) {
+	var link model.Link
+	result := r.db.WithContext(ctx).Where("short_code=? AND case_insensitive=?", strings.ToLower(shortCode), true).First(&link)
+	if result.Error != nil {
+		if result.Error.Error() == gorm.ErrRecordNotFound.Error() {
+			return nil, errors.ErrLinkNotFound
+		}
+		return nil, &errors.RepositoryError{Operation: "FindCaseInsensitive", Err: result.Error}
+	}
+	return &link, nil
+}
+
 func (r *MySQLRepository) Exists(ctx context.Context, shortCode string) (bool, error) {
 	var count int64
-	result := r.db.WithContext(ctx).Model(&model.Link{}).Where("short_code=?", shortCode).Count(&count)
+	result := r.db.WithContext(ctx).Model(&model.Link{}).
+		Where("short_code=? OR (short_code=? AND case_insensitive=?)", shortCode, strings.ToLower(shortCode), true).
+		Count(&count)
 	if result.Error != nil {
 		return false, &errors.RepositoryError{Operation: "Exists", Err: result.Error}
 	}
 	return count > 0, nil
 }
 
-func (r *MySQLRepository) Update(ctx context.Context, link *model.Link) error {
-	result := r.db.WithContext(ctx).Save(link)
+// ExistsIgnoreCase 需要对 short_code 做函数运算，无法使用索引，仅用于创建不区分大小写的自定义短码
+func (r *MySQLRepository) ExistsIgnoreCase(ctx context.Context, shortCode string) (bool, error) {
+	var count int64
+	result := r.db.WithContext(ctx).Model(&model.Link{}).Where("LOWER(short_code)=?", strings.ToLower(shortCode)).Count(&count)
+	if result.Error != nil {
+		return false, &errors.RepositoryError{Operation: "ExistsIgnoreCase", Err: result.Error}
+	}
+	return count > 0, nil
+}
+
+func (r *MySQLRepository) ExistingCodes(ctx context.Context, shortCodes []string) ([]string, error) {
+	var existing []string
+	if len(shortCodes) == 0 {
+		return existing, nil
+	}
+	lowered := make([]string, len(shortCodes))
+	for i, shortCode := range shortCodes {
+		lowered[i] = strings.ToLower(shortCode)
+	}
+
+	var links []model.Link
+	result := r.db.WithContext(ctx).Select("short_code", "case_insensitive").
+		Where("short_code IN ? OR (short_code IN ? AND case_insensitive=?)", shortCodes, lowered, true).
+		Find(&links)
+	if result.Error != nil {
+		return nil, &errors.RepositoryError{Operation: "ExistingCodes", Err: result.Error}
+	}
+
+	exact := make(map[string]bool, len(links))
+	folded := make(map[string]bool)
+	for _, link := range links {
+		exact[link.ShortCode] = true
+		if link.CaseInsensitive {
+			folded[link.ShortCode] = true
+		}
+	}
+	for i, shortCode := range shortCodes {
+		if exact[shortCode] || folded[lowered[i]] {
+			existing = append(existing, shortCode)
+		}
+	}
+	return existing, nil
+}
+
+func (r *MySQLRepository) Update(ctx context.Context, link *model.Link, columns ...string) error {
+	// 只写入指定的列，读取之后并发更新的点击数等不会被旧值覆盖；Select 同时允许写入零值，如取消密码
+	columns = append(columns, "updated_at")
+	result := r.db.WithContext(ctx).Model(link).Select(columns).Updates(link)
 	if result.Error != nil {
 		return &errors.RepositoryError{Operation: "Update", Err: result.Error}
 	}
@@ -79,6 +149,37 @@ func (r *MySQLRepository) UpdateClickCount(ctx context.Context, shortCode string
 	return nil
 }
 
+func (r *MySQLRepository) UpdateGeoRules(ctx context.Context, shortCode string, rules model.GeoRules) error {
+	result := r.db.WithContext(ctx).Model(&model.Link{}).
+		Where("short_code=?", shortCode).
+		Update("geo_rules", rules)
+	if result.Error != nil {
+		return &errors.RepositoryError{Operation: "UpdateGeoRules", Err: result.Error}
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+	// MySQL 的影响行数不包含值未变化的行，规则与原来相同时同样为 0，需单独确认链接是否存在
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&model.Link{}).Where("short_code=?", shortCode).Count(&count).Error; err != nil {
+		return &errors.RepositoryError{Operation: "UpdateGeoRules", Err: err}
+	}
+	if count == 0 {
+		return errors.ErrLinkNotFound
+	}
+	return nil
+}
+
+func (r *MySQLRepository) MarkExhausted(ctx context.Context, shortCode string) error {
+	result := r.db.WithContext(ctx).Model(&model.Link{}).
+		Where("short_code=? AND status=?", shortCode, model.LinkStatusActive).
+		Update("status", model.LinkStatusExhausted)
+	if result.Error != nil {
+		return &errors.RepositoryError{Operation: "MarkExhausted", Err: result.Error}
+	}
+	return nil
+}
+
 func (r *MySQLRepository) Delete(ctx context.Context, link *model.Link) error {
 	result := r.db.WithContext(ctx).Model(&model.Link{}).
 		Where("id = ? AND version = ?", link.ID, link.Version).
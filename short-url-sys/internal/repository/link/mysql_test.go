@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"short-url-sys/internal/model"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// newDryRunRepository 只生成 SQL 不连接数据库，返回最近一条 UPDATE 语句
+func newDryRunRepository(t *testing.T) (*MySQLRepository, *string) {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{DSN: "user:pass@tcp(127.0.0.1:3306)/short_url", SkipInitializeWithVersion: true}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sql string
+	err = db.Callback().Update().After("gorm:update").Register("test:capture", func(db *gorm.DB) {
+		sql = db.Statement.SQL.String()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewMySQLRepository(db), &sql
+}
+
+func TestUpdate_OnlyWritesSelectedColumns(t *testing.T) {
+	repo, sql := newDryRunRepository(t)
+	link := &model.Link{
+		ID:          7,
+		ShortCode:   "abc",
+		Description: "new",
+		ClickCount:  3,
+		GeoRules:    model.GeoRules{{Country: "US", URL: "https://example.com/us"}},
+	}
+	if err := repo.Update(context.Background(), link, "description", "password_hash"); err != nil {
+		t.Fatal(err)
+	}
+	for _, column := range []string{"`description`", "`password_hash`", "`updated_at`"} {
+		if !strings.Contains(*sql, column) {
+			t.Errorf("UPDATE %q does not set %s", *sql, column)
+		}
+	}
+	for _, column := range []string{"click_count", "geo_rules", "long_url", "status"} {
+		if strings.Contains(*sql, column) {
+			t.Errorf("UPDATE %q overwrites %s", *sql, column)
+		}
+	}
+}
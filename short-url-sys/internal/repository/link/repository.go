@@ -12,12 +12,24 @@ type Repository interface {
 
 	// FindByShortCode 查询链接
 	FindByShortCode(ctx context.Context, shortCode string) (*model.Link, error)
-	FindByLongURL(ctx context.Context, longURL string) (*model.Link, error)
+	// FindCaseInsensitive 按小写短码查询不区分大小写的链接
+	FindCaseInsensitive(ctx context.Context, shortCode string) (*model.Link, error)
+	// FindByLongURL 查询创建者已有的指向相同标准化链接且当前有效、无需密码、不限过期时间和点击次数、没有地域跳转规则的链接
+	FindByLongURL(ctx context.Context, longURL, createdBy string) (*model.Link, error)
+	// Exists 短码是否已被占用，包括被不区分大小写的链接占用
 	Exists(ctx context.Context, shortCode string) (bool, error)
+	// ExistsIgnoreCase 是否存在与短码仅大小写不同的链接
+	ExistsIgnoreCase(ctx context.Context, shortCode string) (bool, error)
+	// ExistingCodes 返回 shortCodes 中已被占用的短码
+	ExistingCodes(ctx context.Context, shortCodes []string) ([]string, error)
 
-	// Update 更新链接
-	Update(ctx context.Context, link *model.Link) error
+	// Update 更新链接的 columns 列，updated_at 总是更新；其余列（如点击数、地域跳转规则）保持数据库中的值
+	Update(ctx context.Context, link *model.Link, columns ...string) error
 	UpdateClickCount(ctx context.Context, shortCode string, increment int64) error
+	// UpdateGeoRules 只更新链接的地域跳转规则
+	UpdateGeoRules(ctx context.Context, shortCode string, rules model.GeoRules) error
+	// MarkExhausted 将点击次数达到上限的有效链接标记为 exhausted
+	MarkExhausted(ctx context.Context, shortCode string) error
 
 	// Delete 删除链接
 	Delete(ctx context.Context, link *model.Link) error
@@ -0,0 +1,39 @@
+package segment
+
+import (
+	"context"
+	"fmt"
+	"short-url-sys/internal/model"
+	"short-url-sys/internal/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+type MySQLRepository struct {
+	db *gorm.DB
+}
+
+func (r *MySQLRepository) Allocate(ctx context.Context, bizTag string) (*model.IDSegment, error) {
+	var segment model.IDSegment
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 行锁保证多个实例分配到的号段互不重叠
+		result := tx.Model(&model.IDSegment{}).
+			Where("biz_tag = ?", bizTag).
+			Update("max_id", gorm.Expr("max_id + step"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("biz_tag %q not found in id_segments", bizTag)
+		}
+		return tx.Where("biz_tag = ?", bizTag).First(&segment).Error
+	})
+	if err != nil {
+		return nil, &errors.RepositoryError{Operation: "Allocate", Err: err}
+	}
+	return &segment, nil
+}
+
+func NewMySQLRepository(db *gorm.DB) *MySQLRepository {
+	return &MySQLRepository{db: db}
+}
@@ -0,0 +1,12 @@
+package segment
+
+import (
+	"context"
+	"short-url-sys/internal/model"
+)
+
+// Repository ID号段数据访问接口
+type Repository interface {
+	// Allocate 为 bizTag 分配下一个号段，返回更新后的号段
+	Allocate(ctx context.Context, bizTag string) (*model.IDSegment, error)
+}
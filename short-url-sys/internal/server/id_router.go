@@ -0,0 +1,76 @@
+package server
+
+import (
+	"short-url-sys/internal/config"
+	"short-url-sys/internal/handler"
+	"short-url-sys/internal/model"
+	"short-url-sys/internal/server/middleware"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupIDRouter(config *config.Config, srv *IDServer) *gin.Engine {
+	// 设置Gin模式
+	gin.SetMode(config.Server.IDServer.Mode)
+	router := gin.New()
+
+	// 全局中间件
+	router.Use(gin.Recovery())
+	router.Use(middleware.ErrorHandler())
+
+	// 初始化处理器
+	idHandler := handler.NewIDHandler(srv.idGenerator, config.Server.IDServer.MaxBatch)
+
+	// 健康检查端点
+	router.GET(healthPath, func(c *gin.Context) {
+		health := model.HealthResponse{
+			Status:    "ok",
+			Timestamp: time.Now().Unix(),
+			Services:  make(map[string]string),
+		}
+
+		// 检查节点ID租约
+		if srv.idGenerator.LeaseValid() {
+			health.Services["node_lease"] = "healthy"
+		} else {
+			health.Status = "unavailable"
+			health.Services["node_lease"] = "lost"
+		}
+
+		// 检查Redis连接
+		if srv.redisClient != nil {
+			if err := srv.redisClient.HealthCheck(); err != nil {
+				health.Status = "degraded"
+				health.Services["redis"] = "unhealthy"
+			} else {
+				health.Services["redis"] = "healthy"
+			}
+		}
+
+		statusCode := 200
+		if health.Status == "unavailable" {
+			statusCode = 503
+		}
+		c.JSON(statusCode, health)
+	})
+
+	api := router.Group(apiV1Path)
+	{
+		ids := api.Group("/ids")
+		{
+			ids.POST("/next", idHandler.NextID)
+			ids.POST("/batch", idHandler.BatchIDs)
+			ids.GET("/decompose/:id", idHandler.DecomposeID)
+		}
+
+		api.GET("/info", func(c *gin.Context) {
+			c.JSON(200, gin.H{
+				"service": "id-server",
+				"version": "1.0.0",
+			})
+		})
+	}
+
+	return router
+}
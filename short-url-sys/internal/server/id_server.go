@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"short-url-sys/internal/config"
+	"short-url-sys/internal/pkg/database"
+	"short-url-sys/internal/service/idgen"
+	"syscall"
+	"time"
+)
+
+// IDServer 对外提供雪花算法ID的发号服务
+type IDServer struct {
+	config      *config.Config
+	router      http.Handler
+	server      *http.Server
+	redisClient *database.RedisClient
+	idGenerator *idgen.Snowflake
+}
+
+func NewIDServer(config *config.Config) *IDServer {
+	return &IDServer{
+		config: config,
+	}
+}
+
+func (s *IDServer) initDatabase() error {
+	// 仅在租用节点ID时需要Redis
+	if !s.config.IdGenerator.Snowflake.Lease.Enabled {
+		return nil
+	}
+	redisClient, err := database.NewRedis(&s.config.Redis)
+	if err != nil {
+		return fmt.Errorf("init redis failed: %w", err)
+	}
+	s.redisClient = redisClient
+
+	log.Printf("✅ init database success\n")
+	return nil
+}
+
+func (s *IDServer) initServices() error {
+	// 初始化ID生成器
+	snowflakeCfg := s.config.IdGenerator.Snowflake
+	if s.config.Server.IDServer.NodeID != nil {
+		snowflakeCfg.NodeID = *s.config.Server.IDServer.NodeID
+	}
+	if s.config.Server.IDServer.StateFile != nil {
+		snowflakeCfg.StateFile = *s.config.Server.IDServer.StateFile
+	}
+	idGenerator, err := idgen.NewSnowflakeFromConfig(&snowflakeCfg, s.redisClient)
+	if err != nil {
+		return fmt.Errorf("init ID Generator failed: %w", err)
+	}
+	s.idGenerator = idGenerator
+
+	log.Println("✅ Services initialized successfully")
+	return nil
+}
+
+func (s *IDServer) Start() error {
+	// 初始化数据库
+	if err := s.initDatabase(); err != nil {
+		return fmt.Errorf("failed to init database: %w", err)
+	}
+
+	// 初始化服务
+	if err := s.initServices(); err != nil {
+		return fmt.Errorf("failed to init services: %w", err)
+	}
+
+	// 设置路由
+	s.router = SetupIDRouter(s.config, s)
+	idServer := s.config.Server.IDServer
+	addr := fmt.Sprintf("%s:%d", idServer.Host, idServer.Port)
+	s.server = &http.Server{
+		Addr:         addr,
+		Handler:      s.router,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+
+	// 启动服务器
+	go func() {
+		log.Printf("server listening on %s", addr)
+		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	// 等待中断信号
+	s.waitForShutdown()
+	return nil
+}
+
+func (s *IDServer) waitForShutdown() {
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Printf("Shutting down server...\n")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.server.Shutdown(ctx); err != nil {
+		log.Fatalf("Server forced to shutdown: %v", err)
+	}
+
+	// 持久化时间戳并释放节点ID租约
+	if err := s.idGenerator.Close(); err != nil {
+		log.Printf("Failed to close ID generator: %v\n", err)
+	}
+	if s.redisClient != nil {
+		s.redisClient.Close()
+	}
+	log.Printf("Server exiting...\n")
+}
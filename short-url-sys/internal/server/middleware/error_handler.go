@@ -25,64 +25,100 @@ func ErrorHandler() gin.HandlerFunc {
 			var statusCode int
 			var errorResp model.ErrorResponse
 
-			switch err := lastError.(type) {
-			case *errors.BusinessError:
+			// 先匹配已知的业务错误，它们同样是 *errors.BusinessError，需要在类型判断之前处理
+			switch lastError {
+			case errors.ErrLinkNotFound:
+				statusCode = http.StatusNotFound
+				errorResp = model.ErrorResponse{
+					Error:   "link_not_found",
+					Message: "Short link not found",
+				}
+			case errors.ErrLinkExpired:
+				statusCode = http.StatusGone
+				errorResp = model.ErrorResponse{
+					Error:   "link_expired",
+					Message: "Short link has expired",
+				}
+			case errors.ErrLinkDisabled:
+				statusCode = http.StatusForbidden
+				errorResp = model.ErrorResponse{
+					Error:   "link_disabled",
+					Message: "Short link is disabled",
+				}
+			case errors.ErrLinkExhausted:
+				statusCode = http.StatusGone
+				errorResp = model.ErrorResponse{
+					Error:   "link_exhausted",
+					Message: "Short link has reached its click limit",
+				}
+			case errors.ErrLinkNotStarted:
+				statusCode = http.StatusForbidden
+				errorResp = model.ErrorResponse{
+					Error:   "link_not_started",
+					Message: "Short link is not yet available",
+				}
+			case errors.ErrInvalidURL:
+				statusCode = http.StatusBadRequest
+				errorResp = model.ErrorResponse{
+					Error:   "invalid_url",
+					Message: "Invalid URL format",
+				}
+			case errors.ErrShortCodeExists:
+				statusCode = http.StatusConflict
+				errorResp = model.ErrorResponse{
+					Error:   "short_code_exists",
+					Message: "Short code already exists",
+				}
+			case errors.ErrInvalidShortCode:
 				statusCode = http.StatusBadRequest
 				errorResp = model.ErrorResponse{
-					Error:   "business_error",
-					Message: err.Error(),
+					Error:   "invalid_short_code",
+					Message: "Invalid short code format",
 				}
-			case *errors.ValidationError:
+			case errors.ErrShortCodeNotAllowed:
 				statusCode = http.StatusBadRequest
 				errorResp = model.ErrorResponse{
-					Error:   "validation_error",
-					Message: err.Error(),
-					Code:    err.Field,
+					Error:   "short_code_not_allowed",
+					Message: "Short code is reserved or not allowed",
 				}
-			case *errors.RepositoryError:
-				statusCode = http.StatusInternalServerError
+			case errors.ErrPasswordRequired:
+				statusCode = http.StatusUnauthorized
 				errorResp = model.ErrorResponse{
-					Error:   "internal_error",
-					Message: "Internal server error",
+					Error:   "password_required",
+					Message: "Short link is password protected",
+				}
+			case errors.ErrPasswordInvalid:
+				statusCode = http.StatusUnauthorized
+				errorResp = model.ErrorResponse{
+					Error:   "password_invalid",
+					Message: "Invalid password",
+				}
+			case errors.ErrTooManyAttempts:
+				statusCode = http.StatusTooManyRequests
+				errorResp = model.ErrorResponse{
+					Error:   "too_many_attempts",
+					Message: "Too many failed password attempts, please try again later",
 				}
 			default:
-				// 处理已知的业务错误
-				switch lastError {
-				case errors.ErrLinkNotFound:
-					statusCode = http.StatusNotFound
-					errorResp = model.ErrorResponse{
-						Error:   "link_not_found",
-						Message: "Short link not found",
-					}
-				case errors.ErrLinkExpired:
-					statusCode = http.StatusGone
-					errorResp = model.ErrorResponse{
-						Error:   "link_expired",
-						Message: "Short link has expired",
-					}
-				case errors.ErrLinkDisabled:
-					statusCode = http.StatusForbidden
-					errorResp = model.ErrorResponse{
-						Error:   "link_disabled",
-						Message: "Short link is disabled",
-					}
-				case errors.ErrInvalidURL:
+				switch err := lastError.(type) {
+				case *errors.BusinessError:
 					statusCode = http.StatusBadRequest
 					errorResp = model.ErrorResponse{
-						Error:   "invalid_url",
-						Message: "Invalid URL format",
+						Error:   "business_error",
+						Message: err.Error(),
 					}
-				case errors.ErrShortCodeExists:
-					statusCode = http.StatusConflict
+				case *errors.ValidationError:
+					statusCode = http.StatusBadRequest
 					errorResp = model.ErrorResponse{
-						Error:   "short_code_exists",
-						Message: "Short code already exists",
+						Error:   "validation_error",
+						Message: err.Error(),
+						Code:    err.Field,
 					}
-				case errors.ErrInvalidShortCode:
-					statusCode = http.StatusBadRequest
+				case *errors.RepositoryError:
+					statusCode = http.StatusInternalServerError
 					errorResp = model.ErrorResponse{
-						Error:   "invalid_short_code",
-						Message: "Invalid short code format",
+						Error:   "internal_error",
+						Message: "Internal server error",
 					}
 				default:
 					statusCode = http.StatusInternalServerError
@@ -4,6 +4,7 @@ import (
 	"short-url-sys/internal/config"
 	"short-url-sys/internal/handler"
 	"short-url-sys/internal/model"
+	"short-url-sys/internal/server/middleware"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -16,13 +17,13 @@ func SetupRedirectRouter(config *config.Config, srv *RedirectServer) *gin.Engine
 
 	// 设置全局中间件
 	//router.Use(gin.Recovery())
-	//router.Use(middleware.ErrorHandler())
+	router.Use(middleware.ErrorHandler())
 
 	// 初始化处理器
-	redirectHandler := handler.NewRedirectHandler(srv.redirectSvc)
+	redirectHandler := handler.NewRedirectHandler(srv.redirectSvc, srv.codeGenerator)
 
 	// 健康检查点
-	router.GET("/health", func(c *gin.Context) {
+	router.GET(healthPath, func(c *gin.Context) {
 		c.JSON(200, gin.H{
 			"status":    "ok",
 			"service":   "redirect",
@@ -32,6 +33,7 @@ func SetupRedirectRouter(config *config.Config, srv *RedirectServer) *gin.Engine
 
 	// 重定向路由
 	router.GET("/:code", redirectHandler.Redirect)
+	router.POST("/:code", redirectHandler.SubmitPassword)
 
 	// 跟路径
 	router.GET("/", func(c *gin.Context) {
@@ -9,24 +9,27 @@ import (
 	"os/signal"
 	"short-url-sys/internal/config"
 	"short-url-sys/internal/pkg/database"
+	"short-url-sys/internal/pkg/signing"
 	"short-url-sys/internal/repository/cache"
 	linkRepo "short-url-sys/internal/repository/link"
 	statsRepo "short-url-sys/internal/repository/stats"
+	linkService "short-url-sys/internal/service/link"
 	redirectService "short-url-sys/internal/service/redirect"
 	"syscall"
 	"time"
 )
 
 type RedirectServer struct {
-	config      *config.Config
-	router      http.Handler
-	server      *http.Server
-	mysqlDB     *database.MySQLDB
-	redisClient *database.RedisClient
-	redirectSvc redirectService.Service
-	linkRepo    linkRepo.Repository
-	statsRepo   statsRepo.Repository
-	cacheRepo   *cache.Repository
+	config        *config.Config
+	router        http.Handler
+	server        *http.Server
+	mysqlDB       *database.MySQLDB
+	redisClient   *database.RedisClient
+	redirectSvc   redirectService.Service
+	codeGenerator *linkService.ShortCodeGenerator
+	linkRepo      linkRepo.Repository
+	statsRepo     statsRepo.Repository
+	cacheRepo     *cache.Repository
 }
 
 func NewRedirectServer(config *config.Config) *RedirectServer {
@@ -53,25 +56,65 @@ func (s *RedirectServer) initDatabase() error {
 	// 初始化Repository
 	s.linkRepo = linkRepo.NewMySQLRepository(mysqlDB.DB)
 	s.statsRepo = statsRepo.NewMySQLRepository(mysqlDB.DB)
-	s.cacheRepo = cache.NewRepository(redisClient.Client, &s.config.Cache)
+	s.cacheRepo = cache.NewRepository(redisClient.Client, &s.config.Cache, s.config.ShortCode.CaseInsensitive)
 
 	log.Printf("✅ init database success\n")
 	return nil
 }
 
 func (s *RedirectServer) initServices() error {
+	// 与 api-server 使用相同的短码配置，用于校验短码
+	codeGenerator, err := linkService.NewShortCodeGenerator(&s.config.ShortCode)
+	if err != nil {
+		return fmt.Errorf("init short code generator failed: %w", err)
+	}
+	s.codeGenerator = codeGenerator
 
 	// 初始化重定向服务
+	redirectCfg, err := newRedirectConfig(&s.config.Server.RedirectServer)
+	if err != nil {
+		return fmt.Errorf("init redirect config failed: %w", err)
+	}
 	s.redirectSvc = redirectService.NewRedirectRequest(
 		s.linkRepo,
 		s.statsRepo,
 		s.cacheRepo,
+		redirectCfg,
 	)
 
 	log.Println("✅ Services initialized successfully")
 	return nil
 }
 
+// placeholderSecret 早期示例配置中的占位密钥，公开可见，不能用于签名
+const placeholderSecret = "change-me-in-production"
+
+// newRedirectConfig api-server 和 redirect-server 都处理短码重定向，使用相同的重定向配置
+func newRedirectConfig(redirectCfg *config.RedirectConfig) (redirectService.Config, error) {
+	cfg := &redirectCfg.Password
+	var signer *signing.Signer
+	var err error
+	switch cfg.Secret {
+	case placeholderSecret:
+		return redirectService.Config{}, fmt.Errorf("server.redirect.password.secret must not be the placeholder %q", placeholderSecret)
+	case "":
+		log.Println("⚠️ server.redirect.password.secret is empty, access cookies are only valid within this process")
+		signer, err = signing.NewRandom()
+	default:
+		signer, err = signing.New([]byte(cfg.Secret))
+	}
+	if err != nil {
+		return redirectService.Config{}, err
+	}
+	return redirectService.Config{
+		Signer:              signer,
+		AccessTokenTTL:      cfg.CookieTTL,
+		MaxPasswordAttempts: cfg.MaxAttempts,
+		LockoutWindow:       cfg.LockoutWindow,
+		TeaserURL:           redirectCfg.TeaserURL,
+	}, nil
+}
+
 func (s *RedirectServer) Start() error {
 	// 初始化数据库
 	if err := s.initDatabase(); err != nil {
@@ -0,0 +1,11 @@
+package server
+
+// api-server 和 redirect-server 都在根路径下处理短码，同名短码会被根路径下的其他路由遮蔽，
+// 注册这些路由时使用下面的常量，新增根路径路由时需同时加入 reservedPrefixes
+const (
+	healthPath = "/health"
+	apiV1Path  = "/api/v1"
+)
+
+// reservedPrefixes 根路径下路由的第一段路径，作为保留短码
+var reservedPrefixes = []string{"health", "api"}
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"short-url-sys/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 根路径下新增的路由必须加入 reservedPrefixes，否则同名短码会被遮蔽
+func TestReservedPrefixesCoverRootRoutes(t *testing.T) {
+	cfg := &config.Config{}
+	apiServer := &Server{}
+	SetupRouter(cfg, apiServer)
+	engines := map[string]*gin.Engine{
+		"api-server":      apiServer.router.(*gin.Engine),
+		"redirect-server": SetupRedirectRouter(cfg, &RedirectServer{}),
+	}
+	for name, engine := range engines {
+		for _, route := range engine.Routes() {
+			prefix, _, _ := strings.Cut(strings.TrimPrefix(route.Path, "/"), "/")
+			if prefix == "" || prefix[0] == ':' || prefix[0] == '*' {
+				continue
+			}
+			if !slices.Contains(reservedPrefixes, prefix) {
+				t.Errorf("%s route %s %s: prefix %q missing from reservedPrefixes", name, route.Method, route.Path, prefix)
+			}
+		}
+	}
+}
@@ -22,12 +22,12 @@ func SetupRouter(config *config.Config, srv *Server) {
 
 	// 初始化处理器
 	linkHandler := handler.NewLinkHandler(srv.linkSvc, config.Server.APIServer.BaseURL)
-	redirectHandler := handler.NewRedirectHandler(srv.redirectSvc)
+	redirectHandler := handler.NewRedirectHandler(srv.redirectSvc, srv.codeGenerator)
 	statsHandler := handler.NewStatsHandler(srv.statsSvc)
 	qrcodeHandler := handler.NewQRCodeHandler(srv.linkSvc, config.Server.APIServer.BaseURL)
 
 	// 健康检查端点
-	router.GET("/health", func(c *gin.Context) {
+	router.GET(healthPath, func(c *gin.Context) {
 		health := model.HealthResponse{
 			Status:    "ok",
 			Timestamp: time.Now().Unix(),
@@ -53,19 +53,31 @@ func SetupRouter(config *config.Config, srv *Server) {
 				health.Services["redis"] = "healthy"
 			}
 		}
+
+		// 短码池剩余数量
+		if srv.codePool != nil {
+			if depth, err := srv.codePool.Depth(c.Request.Context()); err == nil {
+				health.Metrics = map[string]int64{"code_pool_depth": depth}
+			}
+		}
 		c.JSON(200, health)
 	})
 
-	api := router.Group("/api/v1")
+	api := router.Group(apiV1Path)
 	api.Use(middleware.RateLimit(10)) // 每分钟10个请求
 	{
 		// 短链相关接口
 		links := api.Group("/links")
 		{
 			links.POST("/short", linkHandler.CreateShortURL)
+			links.GET("/availability/:code", linkHandler.CheckAvailability)
 			links.GET("/:code", linkHandler.GetLinkInfo)
 			links.PUT("/:code", linkHandler.UpdateLink)
 			links.DELETE("/:code", linkHandler.DeleteLink)
+			links.GET("/:code/rules", linkHandler.GetGeoRules)
+			links.PUT("/:code/rules", linkHandler.SetGeoRules)
+			links.POST("/:code/rules", linkHandler.AddGeoRule)
+			links.DELETE("/:code/rules", linkHandler.DeleteGeoRules)
 			links.GET("", linkHandler.ListLinks)
 			links.POST("/short/batch", linkHandler.BatchCreate)
 
@@ -93,5 +105,6 @@ func SetupRouter(config *config.Config, srv *Server) {
 
 	// 重定向路由（放在最后，避免被API路由捕获）
 	router.GET("/:code", redirectHandler.Redirect)
+	router.POST("/:code", redirectHandler.SubmitPassword)
 	srv.router = router
 }
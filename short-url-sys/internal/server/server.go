@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
@@ -22,18 +23,20 @@ import (
 )
 
 type Server struct {
-	config      *config.Config
-	router      http.Handler
-	server      *http.Server
-	mysqlDB     *database.MySQLDB
-	redisClient *database.RedisClient
-	linkRepo    linkRepo.Repository
-	statsRepo   statsRepo.Repository
-	cacheRepo   *cache.Repository
-	idGenerator idgen.Generator
-	linkSvc     linkService.Service
-	redirectSvc redirectService.Service
-	statsSvc    statsService.Service
+	config        *config.Config
+	router        http.Handler
+	server        *http.Server
+	mysqlDB       *database.MySQLDB
+	redisClient   *database.RedisClient
+	linkRepo      linkRepo.Repository
+	statsRepo     statsRepo.Repository
+	cacheRepo     *cache.Repository
+	idGenerator   idgen.Generator
+	codeGenerator *linkService.ShortCodeGenerator
+	codePool      *linkService.CodePool
+	linkSvc       linkService.Service
+	redirectSvc   redirectService.Service
+	statsSvc      statsService.Service
 }
 
 func New(config *config.Config, router http.Handler) *Server {
@@ -61,7 +64,7 @@ func (s *Server) initDatabase() error {
 	// 初始化Repository
 	s.linkRepo = linkRepo.NewMySQLRepository(mysqlDB.DB)
 	s.statsRepo = statsRepo.NewMySQLRepository(mysqlDB.DB)
-	s.cacheRepo = cache.NewRepository(redisClient.Client, &s.config.Cache)
+	s.cacheRepo = cache.NewRepository(redisClient.Client, &s.config.Cache, s.config.ShortCode.CaseInsensitive)
 
 	log.Printf("✅ init database success\n")
 	return nil
@@ -69,12 +72,32 @@ func (s *Server) initDatabase() error {
 
 func (s *Server) initServices() error {
 	// 初始化ID生成器
-	idGenerator, err := idgen.NewIDGenerator(&s.config.IdGenerator, s.redisClient)
+	idGenerator, err := idgen.NewIDGenerator(&s.config.IdGenerator, s.redisClient, s.mysqlDB)
 	if err != nil {
 		return fmt.Errorf("init ID Generator failed: %w", err)
 	}
 	s.idGenerator = idGenerator
 
+	codeGenerator, err := linkService.NewShortCodeGenerator(&s.config.ShortCode)
+	if err != nil {
+		return fmt.Errorf("init short code generator failed: %w", err)
+	}
+	codeGenerator.Reserve(reservedPrefixes...)
+	var maxID uint64
+	if bounded, ok := s.idGenerator.(idgen.Bounded); ok {
+		maxID = bounded.MaxID()
+	}
+	if err := codeGenerator.CheckCapacity(maxID); err != nil {
+		return fmt.Errorf("short_code does not fit id_generator %s: %w", s.idGenerator, err)
+	}
+	s.codeGenerator = codeGenerator
+
+	// 初始化短码池
+	if poolCfg := &s.config.ShortCode.Pool; poolCfg.Enabled {
+		s.codePool = linkService.NewCodePool(s.redisClient.Client, poolCfg, s.idGenerator, codeGenerator, s.linkRepo)
+		s.codePool.Start()
+	}
+
 	// 初始化短链服务
 	s.linkSvc = linkService.NewService(
 		s.linkRepo,
@@ -82,15 +105,23 @@ func (s *Server) initServices() error {
 		s.cacheRepo,
 		s.idGenerator,
 		linkService.Config{
-			BaseURL: s.config.Server.APIServer.BaseURL,
+			BaseURL:       s.config.Server.APIServer.BaseURL,
+			CodeGenerator: codeGenerator,
+			CodePool:      s.codePool,
+			ReuseExisting: s.config.Server.APIServer.ReuseExisting,
 		},
 	)
 
 	// 初始化重定向服务
+	redirectCfg, err := newRedirectConfig(&s.config.Server.RedirectServer)
+	if err != nil {
+		return fmt.Errorf("init redirect config failed: %w", err)
+	}
 	s.redirectSvc = redirectService.NewRedirectRequest(
 		s.linkRepo,
 		s.statsRepo,
 		s.cacheRepo,
+		redirectCfg,
 	)
 
 	// 初始化统计服务
@@ -148,6 +179,18 @@ func (s *Server) waitForShutdown() {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	// 停止短码池补充，补充任务依赖ID生成器
+	if s.codePool != nil {
+		s.codePool.Close()
+	}
+
+	// 释放ID生成器持有的资源（如租用的节点ID）
+	if closer, ok := s.idGenerator.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Printf("Failed to close ID generator: %v\n", err)
+		}
+	}
+
 	// 关闭数据库连接
 	if s.mysqlDB != nil {
 		s.mysqlDB.Close()
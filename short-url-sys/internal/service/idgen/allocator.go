@@ -0,0 +1,121 @@
+package idgen
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	rangeLoadTimeout = 5 * time.Second
+	// defaultPrefetchThreshold 当前号段剩余比例不高于该值时预取，即消耗 10% 后开始预取下一段
+	defaultPrefetchThreshold = 0.9
+)
+
+// segment 一个已分配的号段，可发放 [cursor, max] 之间的ID
+type segment struct {
+	cursor uint64
+	max    uint64
+	step   uint64
+}
+
+func (s *segment) remaining() uint64 {
+	if s == nil || s.cursor > s.max {
+		return 0
+	}
+	return s.max - s.cursor + 1
+}
+
+// rangeAllocator 双缓冲的号段分配器：当前号段剩余比例低于阈值时在后台预取下一个号段，
+// 号段耗尽而预取未完成时调用方等待加载，等待可被 ctx 取消
+type rangeAllocator struct {
+	name      string
+	load      func(ctx context.Context) (*segment, error)
+	threshold float64
+
+	mu      sync.Mutex
+	current *segment
+	next    *segment
+	loading chan struct{} // 加载进行中时非空，加载完成后关闭
+	loadErr error
+}
+
+// newRangeAllocator threshold 为触发预取的剩余比例，取值 (0, 1)，否则使用默认值
+func newRangeAllocator(name string, threshold float64, load func(ctx context.Context) (*segment, error)) *rangeAllocator {
+	if threshold <= 0 || threshold >= 1 {
+		threshold = defaultPrefetchThreshold
+	}
+	return &rangeAllocator{
+		name:      name,
+		load:      load,
+		threshold: threshold,
+	}
+}
+
+func (a *rangeAllocator) take(ctx context.Context, n int) ([]uint64, error) {
+	if n <= 0 {
+		return nil, ErrInvalidCount
+	}
+	ids := make([]uint64, 0, n)
+
+	a.mu.Lock()
+	for len(ids) < n {
+		if a.current.remaining() > 0 {
+			ids = append(ids, a.current.cursor)
+			a.current.cursor++
+			continue
+		}
+		if a.next != nil {
+			a.current, a.next = a.next, nil
+			continue
+		}
+
+		// 当前号段已耗尽，等待加载完成
+		loading := a.startLoad()
+		a.mu.Unlock()
+		select {
+		case <-loading:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		a.mu.Lock()
+		if a.next == nil && a.loadErr != nil {
+			err := a.loadErr
+			a.mu.Unlock()
+			return nil, err
+		}
+	}
+	if a.next == nil && float64(a.current.remaining()) <= float64(a.current.step)*a.threshold {
+		a.startLoad()
+	}
+	a.mu.Unlock()
+	return ids, nil
+}
+
+// startLoad 在后台加载下一个号段，已在加载时直接返回，调用方需持有锁。
+// 加载结果由所有等待者共享，因此不使用调用方的 ctx
+func (a *rangeAllocator) startLoad() <-chan struct{} {
+	if a.loading != nil {
+		return a.loading
+	}
+	done := make(chan struct{})
+	a.loading = done
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), rangeLoadTimeout)
+		seg, err := a.load(ctx)
+		cancel()
+
+		a.mu.Lock()
+		a.loading = nil
+		a.loadErr = err
+		if err != nil {
+			log.Printf("load ID range for %s failed: %v\n", a.name, err)
+		} else {
+			a.next = seg
+		}
+		a.mu.Unlock()
+		close(done)
+	}()
+	return done
+}
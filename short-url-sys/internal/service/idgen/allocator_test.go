@@ -0,0 +1,231 @@
+package idgen
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeLoader 按顺序发放步长为 step 的号段，可注入错误或阻塞加载
+type fakeLoader struct {
+	mu    sync.Mutex
+	step  uint64
+	max   uint64
+	calls int
+	err   error
+	block chan struct{} // 非空时加载阻塞到关闭为止
+}
+
+func (l *fakeLoader) load(ctx context.Context) (*segment, error) {
+	l.mu.Lock()
+	block := l.block
+	l.mu.Unlock()
+	if block != nil {
+		<-block
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls++
+	if l.err != nil {
+		return nil, l.err
+	}
+	l.max += l.step
+	return &segment{cursor: l.max - l.step + 1, max: l.max, step: l.step}, nil
+}
+
+func (l *fakeLoader) loadCalls() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.calls
+}
+
+// waitPrefetch 等待后台预取完成
+func waitPrefetch(t *testing.T, a *rangeAllocator) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		a.mu.Lock()
+		done := a.loading == nil && a.next != nil
+		a.mu.Unlock()
+		if done {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("prefetch did not finish")
+}
+
+func assertSequence(t *testing.T, ids []uint64, first uint64) {
+	t.Helper()
+	for i, id := range ids {
+		if want := first + uint64(i); id != want {
+			t.Fatalf("ids[%d] = %d, want %d", i, id, want)
+		}
+	}
+}
+
+func TestRangeAllocator_InitialLoad(t *testing.T) {
+	loader := &fakeLoader{step: 10}
+	a := newRangeAllocator("test", 0.5, loader.load)
+
+	ids, err := a.take(context.Background(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSequence(t, ids, 1)
+	if calls := loader.loadCalls(); calls != 1 {
+		t.Fatalf("load calls = %d, want 1", calls)
+	}
+}
+
+func TestRangeAllocator_PrefetchOnThreshold(t *testing.T) {
+	loader := &fakeLoader{step: 10}
+	a := newRangeAllocator("test", 0.5, loader.load)
+
+	// 剩余 6/10，未到阈值
+	if _, err := a.take(context.Background(), 4); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if calls := loader.loadCalls(); calls != 1 {
+		t.Fatalf("load calls = %d before threshold, want 1", calls)
+	}
+
+	// 剩余 5/10，触发预取
+	if _, err := a.take(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	waitPrefetch(t, a)
+	if calls := loader.loadCalls(); calls != 2 {
+		t.Fatalf("load calls = %d after threshold, want 2", calls)
+	}
+}
+
+func TestRangeAllocator_SwapsToPrefetchedSegment(t *testing.T) {
+	loader := &fakeLoader{step: 10}
+	a := newRangeAllocator("test", 0.5, loader.load)
+
+	first, err := a.take(context.Background(), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitPrefetch(t, a)
+
+	// 跨越两个号段，切换到预取的号段时不再同步加载
+	rest, err := a.take(context.Background(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSequence(t, append(first, rest...), 1)
+	if calls := loader.loadCalls(); calls != 2 {
+		t.Fatalf("load calls = %d, want 2", calls)
+	}
+}
+
+func TestRangeAllocator_ContextCanceledWhileWaiting(t *testing.T) {
+	block := make(chan struct{})
+	loader := &fakeLoader{step: 10, block: block}
+	a := newRangeAllocator("test", 0.5, loader.load)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := a.take(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+
+	// 加载完成后，后续调用使用已加载的号段
+	close(block)
+	ids, err := a.take(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSequence(t, ids, 1)
+}
+
+func TestRangeAllocator_LoadErrorPropagates(t *testing.T) {
+	loadErr := errors.New("store unavailable")
+	loader := &fakeLoader{step: 10, err: loadErr}
+	a := newRangeAllocator("test", 0.5, loader.load)
+
+	if _, err := a.take(context.Background(), 1); !errors.Is(err, loadErr) {
+		t.Fatalf("err = %v, want %v", err, loadErr)
+	}
+
+	// 错误不会被缓存，恢复后重新加载
+	loader.mu.Lock()
+	loader.err = nil
+	loader.mu.Unlock()
+	ids, err := a.take(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSequence(t, ids, 1)
+}
+
+func TestRangeAllocator_InvalidCount(t *testing.T) {
+	a := newRangeAllocator("test", 0.5, (&fakeLoader{step: 10}).load)
+	if _, err := a.take(context.Background(), 0); !errors.Is(err, ErrInvalidCount) {
+		t.Fatalf("err = %v, want ErrInvalidCount", err)
+	}
+}
+
+func TestRangeAllocator_DefaultThreshold(t *testing.T) {
+	for _, threshold := range []float64{0, -1, 1, 2} {
+		a := newRangeAllocator("test", threshold, (&fakeLoader{step: 10}).load)
+		if a.threshold != defaultPrefetchThreshold {
+			t.Fatalf("threshold %v: got %v, want %v", threshold, a.threshold, defaultPrefetchThreshold)
+		}
+	}
+}
+
+func TestRangeAllocator_ConcurrentUnique(t *testing.T) {
+	loader := &fakeLoader{step: 7}
+	a := newRangeAllocator("test", 0.5, loader.load)
+	assertConcurrentUnique(t, a.take)
+}
+
+// assertConcurrentUnique 并发调用单个与批量取号，检查ID不重复
+func assertConcurrentUnique(t *testing.T, take func(ctx context.Context, n int) ([]uint64, error)) {
+	t.Helper()
+	const (
+		workers = 16
+		rounds  = 100
+	)
+	var (
+		mu   sync.Mutex
+		seen = make(map[uint64]struct{}, workers*rounds*3)
+		wg   sync.WaitGroup
+		errs = make(chan error, workers)
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				ids, err := take(context.Background(), n)
+				if err != nil {
+					errs <- err
+					return
+				}
+				mu.Lock()
+				for _, id := range ids {
+					if _, ok := seen[id]; ok {
+						mu.Unlock()
+						errs <- errors.New("duplicate id")
+						return
+					}
+					seen[id] = struct{}{}
+				}
+				mu.Unlock()
+			}
+		}(w%5 + 1)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+}
@@ -1,18 +1,33 @@
 package idgen
 
 import (
+	"fmt"
 	"short-url-sys/internal/config"
 	"short-url-sys/internal/pkg/database"
+	segmentRepo "short-url-sys/internal/repository/segment"
 )
 
 // NewIDGenerator 根据配置创建ID生成器
-func NewIDGenerator(cfg *config.IDGeneratorConfig, redisClient *database.RedisClient) (Generator, error) {
+func NewIDGenerator(cfg *config.IDGeneratorConfig, redisClient *database.RedisClient, mysqlDB *database.MySQLDB) (Generator, error) {
 	switch cfg.Type {
 	case "redis":
-		return NewRedisGenerator(redisClient.Client, "short_url:id_counter"), nil
+		return NewRedisGenerator(redisClient.Client, redisKey(&cfg.Redis)), nil
+	case "redis_range":
+		return NewRedisRangeGenerator(redisClient.Client, redisKey(&cfg.Redis), cfg.Redis.Step, cfg.Redis.PrefetchThreshold), nil
 	case "snowflake":
-		return NewSnowflake(cfg.Snowflake.NodeID)
+		return NewSnowflakeFromConfig(&cfg.Snowflake, redisClient)
+	case "segment":
+		repo := segmentRepo.NewMySQLRepository(mysqlDB.DB)
+		return NewSegmentGenerator(repo, cfg.Segment.BizTag, cfg.Segment.PrefetchThreshold), nil
 	default:
-		return NewSnowflake(1)
+		return nil, fmt.Errorf("unknown id_generator.type %q, must be one of redis, redis_range, snowflake, segment", cfg.Type)
 	}
 }
+
+// redisKey 返回计数器键，redis 与 redis_range 共用同一个键，可在两者之间切换
+func redisKey(cfg *config.RedisGeneratorConfig) string {
+	if cfg.Key == "" {
+		return defaultRedisKey
+	}
+	return cfg.Key
+}
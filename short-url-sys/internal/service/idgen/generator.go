@@ -1,15 +1,26 @@
 package idgen
 
-import "errors"
+import (
+	"context"
+	"errors"
+)
 
 var (
 	ErrInvalidNodeID = errors.New("invalid node ID")
 	ErrClockBackward = errors.New("clock moved backwards")
+	ErrInvalidCount  = errors.New("invalid ID count")
 )
 
-// Generator ID生成器接口
+// Generator ID生成器接口，ctx 取消时正在等待的调用立即返回
 type Generator interface {
-	NextId() (uint64, error)
+	NextId(ctx context.Context) (uint64, error)
+	// NextIds 一次预留 n 个ID
+	NextIds(ctx context.Context, n int) ([]uint64, error)
 	// String 返回生成器类型
 	String() string
 }
+
+// Bounded 可选接口，发号器可生成的ID存在上限时实现，用于启动时检查短码长度
+type Bounded interface {
+	MaxID() uint64
+}
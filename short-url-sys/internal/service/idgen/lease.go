@@ -0,0 +1,161 @@
+package idgen
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrLeaseLost    = errors.New("node ID lease lost")
+	ErrNoFreeNodeID = errors.New("no free node ID to lease")
+)
+
+// 仅当租约仍属于自己时续期/释放，避免误操作其他实例的租约
+var (
+	renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+	releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// NodeLease 通过Redis租用的节点ID，租约由心跳协程定期续期
+type NodeLease struct {
+	client *redis.Client
+	key    string
+	owner  string
+	ttl    time.Duration
+	nodeID int64
+
+	lost      atomic.Bool
+	deadline  atomic.Int64 // 租约保证有效的截止时间（UnixNano），心跳卡住时由 Valid 自行判定过期
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// AcquireNodeLease 在 [0, maxNodeID] 中依次尝试 SETNX，租用第一个空闲的节点ID
+func AcquireNodeLease(ctx context.Context, client *redis.Client, keyPrefix string, maxNodeID int64, ttl time.Duration) (*NodeLease, error) {
+	owner, err := leaseOwner()
+	if err != nil {
+		return nil, err
+	}
+	for id := int64(0); id <= maxNodeID; id++ {
+		key := fmt.Sprintf("%s:%d", keyPrefix, id)
+		start := time.Now()
+		ok, err := client.SetNX(ctx, key, owner, ttl).Result()
+		if err != nil {
+			return nil, fmt.Errorf("lease node ID %d failed: %w", id, err)
+		}
+		if !ok {
+			continue
+		}
+
+		lease := &NodeLease{
+			client: client,
+			key:    key,
+			owner:  owner,
+			ttl:    ttl,
+			nodeID: id,
+			stop:   make(chan struct{}),
+			done:   make(chan struct{}),
+		}
+		lease.extend(start)
+		go lease.heartbeat()
+		log.Printf("leased node ID %d (%s)\n", id, key)
+		return lease, nil
+	}
+	return nil, ErrNoFreeNodeID
+}
+
+// NodeID 返回租用到的节点ID
+func (l *NodeLease) NodeID() int64 {
+	return l.nodeID
+}
+
+// Valid 租约是否仍然有效，超过最近一次续期的截止时间即视为无效，不依赖心跳协程按时运行
+func (l *NodeLease) Valid() bool {
+	return !l.lost.Load() && time.Now().UnixNano() < l.deadline.Load()
+}
+
+// extend 以续期请求发出的时间 start 计算新的截止时间，预留安全余量应对时钟误差与网络延迟
+func (l *NodeLease) extend(start time.Time) {
+	l.deadline.Store(start.Add(l.ttl - leaseSafetyMargin(l.ttl)).UnixNano())
+}
+
+// leaseSafetyMargin 截止时间相对 Redis 过期时间提前的量
+func leaseSafetyMargin(ttl time.Duration) time.Duration {
+	return ttl / 10
+}
+
+// Release 停止心跳并释放租约
+func (l *NodeLease) Release(ctx context.Context) error {
+	var err error
+	l.closeOnce.Do(func() {
+		close(l.stop)
+		<-l.done
+		l.lost.Store(true)
+		err = releaseScript.Run(ctx, l.client, []string{l.key}, l.owner).Err()
+	})
+	return err
+}
+
+// heartbeat 每 ttl/3 续期一次，租约被他人占用或超过截止时间未能续期时视为丢失
+func (l *NodeLease) heartbeat() {
+	defer close(l.done)
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			start := time.Now()
+			ctx, cancel := context.WithTimeout(context.Background(), l.ttl/3)
+			renewed, err := renewScript.Run(ctx, l.client, []string{l.key}, l.owner, l.ttl.Milliseconds()).Int()
+			cancel()
+			switch {
+			case err == nil && renewed == 1:
+				l.extend(start)
+				continue
+			case err == nil:
+				log.Printf("node ID lease %s taken by another instance\n", l.key)
+			case l.Valid():
+				log.Printf("renew node ID lease %s failed: %v\n", l.key, err)
+				continue
+			default:
+				log.Printf("node ID lease %s expired: %v\n", l.key, err)
+			}
+			l.lost.Store(true)
+			return
+		}
+	}
+}
+
+func leaseOwner() (string, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), hex.EncodeToString(buf)), nil
+}
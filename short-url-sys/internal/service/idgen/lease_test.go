@@ -0,0 +1,72 @@
+package idgen
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"short-url-sys/internal/testutil"
+)
+
+func TestNodeLease_AcquiresFreeNodeIDs(t *testing.T) {
+	_, client := testutil.NewRedis(t)
+	ctx := context.Background()
+
+	first, err := AcquireNodeLease(ctx, client, "test:node", 1, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Release(ctx)
+	second, err := AcquireNodeLease(ctx, client, "test:node", 1, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Release(ctx)
+	if first.NodeID() != 0 || second.NodeID() != 1 {
+		t.Fatalf("node IDs = %d, %d, want 0, 1", first.NodeID(), second.NodeID())
+	}
+	if _, err := AcquireNodeLease(ctx, client, "test:node", 1, time.Minute); err != ErrNoFreeNodeID {
+		t.Fatalf("err = %v, want ErrNoFreeNodeID", err)
+	}
+}
+
+func TestNodeLease_InvalidAfterDeadline(t *testing.T) {
+	mr, client := testutil.NewRedis(t)
+	ctx := context.Background()
+	ttl := 300 * time.Millisecond
+
+	lease, err := AcquireNodeLease(ctx, client, "test:node", 0, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lease.Release(ctx)
+	if !lease.Valid() {
+		t.Fatal("lease invalid right after acquire")
+	}
+
+	// 续期失败时，截止时间（ttl 减去安全余量）之后不再有效
+	mr.SetError("ERR unavailable")
+	time.Sleep(ttl - leaseSafetyMargin(ttl))
+	if lease.Valid() {
+		t.Fatal("lease still valid past its deadline")
+	}
+}
+
+func TestNodeLease_ReleaseInvalidates(t *testing.T) {
+	mr, client := testutil.NewRedis(t)
+	ctx := context.Background()
+
+	lease, err := AcquireNodeLease(ctx, client, "test:node", 0, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lease.Release(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if lease.Valid() {
+		t.Fatal("lease valid after release")
+	}
+	if mr.Exists("test:node:0") {
+		t.Fatal("lease key not deleted on release")
+	}
+}
@@ -6,13 +6,14 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+const defaultRedisKey = "short_url:id_counter"
+
 type RedisGenerator struct {
 	client *redis.Client
 	key    string
 }
 
-func (r *RedisGenerator) NextId() (uint64, error) {
-	ctx := context.Background()
+func (r *RedisGenerator) NextId(ctx context.Context) (uint64, error) {
 	id, err := r.client.Incr(ctx, r.key).Result()
 	if err != nil {
 		return 0, err
@@ -20,6 +21,23 @@ func (r *RedisGenerator) NextId() (uint64, error) {
 	return uint64(id), nil
 }
 
+// NextIds 通过一次 INCRBY 预留连续的 n 个ID
+func (r *RedisGenerator) NextIds(ctx context.Context, n int) ([]uint64, error) {
+	if n <= 0 {
+		return nil, ErrInvalidCount
+	}
+	end, err := r.client.IncrBy(ctx, r.key, int64(n)).Result()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint64, n)
+	start := uint64(end) - uint64(n) + 1
+	for i := range ids {
+		ids[i] = start + uint64(i)
+	}
+	return ids, nil
+}
+
 func (r *RedisGenerator) String() string {
 	return "redis"
 }
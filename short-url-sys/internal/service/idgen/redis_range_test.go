@@ -0,0 +1,130 @@
+package idgen
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"short-url-sys/internal/testutil"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+const testRangeKey = "test:id_counter"
+
+// counter 返回Redis中计数器的当前值
+func counter(t *testing.T, mr *miniredis.Miniredis) string {
+	t.Helper()
+	v, err := mr.Get(testRangeKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func waitCounter(t *testing.T, mr *miniredis.Miniredis, want string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if v, _ := mr.Get(testRangeKey); v == want {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("counter = %s, want %s", counter(t, mr), want)
+}
+
+func TestRedisRangeGenerator_InitialLoad(t *testing.T) {
+	mr, client := testutil.NewRedis(t)
+	g := NewRedisRangeGenerator(client, testRangeKey, 10, 0.5)
+
+	id, err := g.NextId(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 1 {
+		t.Fatalf("id = %d, want 1", id)
+	}
+	if v := counter(t, mr); v != "10" {
+		t.Fatalf("counter = %s, want 10", v)
+	}
+}
+
+func TestRedisRangeGenerator_ContinuesExistingCounter(t *testing.T) {
+	mr, client := testutil.NewRedis(t)
+	// 与 RedisGenerator 共用计数器，切换后从已有值之后继续
+	mr.Set(testRangeKey, "500")
+	g := NewRedisRangeGenerator(client, testRangeKey, 10, 0.5)
+
+	ids, err := g.NextIds(context.Background(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSequence(t, ids, 501)
+}
+
+func TestRedisRangeGenerator_PrefetchOnThreshold(t *testing.T) {
+	mr, client := testutil.NewRedis(t)
+	g := NewRedisRangeGenerator(client, testRangeKey, 10, 0.5)
+
+	if _, err := g.NextIds(context.Background(), 4); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if v := counter(t, mr); v != "10" {
+		t.Fatalf("counter = %s before threshold, want 10", v)
+	}
+
+	if _, err := g.NextId(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	waitCounter(t, mr, "20")
+}
+
+func TestRedisRangeGenerator_SwapsToPrefetchedSegment(t *testing.T) {
+	mr, client := testutil.NewRedis(t)
+	g := NewRedisRangeGenerator(client, testRangeKey, 10, 0.5)
+
+	first, err := g.NextIds(context.Background(), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitCounter(t, mr, "20")
+	rest, err := g.NextIds(context.Background(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSequence(t, append(first, rest...), 1)
+}
+
+func TestRedisRangeGenerator_LoadError(t *testing.T) {
+	mr, client := testutil.NewRedis(t)
+	g := NewRedisRangeGenerator(client, testRangeKey, 10, 0.5)
+
+	mr.SetError("LOADING Redis is loading the dataset in memory")
+	if _, err := g.NextId(context.Background()); err == nil {
+		t.Fatal("NextId succeeded while Redis is failing")
+	}
+
+	mr.SetError("")
+	id, err := g.NextId(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 1 {
+		t.Fatalf("id = %d, want 1", id)
+	}
+}
+
+func TestRedisRangeGenerator_ConcurrentUnique(t *testing.T) {
+	_, client := testutil.NewRedis(t)
+	// 两个实例共用计数器，模拟多进程部署
+	a := NewRedisRangeGenerator(client, testRangeKey, 7, 0.5)
+	b := NewRedisRangeGenerator(client, testRangeKey, 7, 0.5)
+	assertConcurrentUnique(t, func(ctx context.Context, count int) ([]uint64, error) {
+		if count%2 == 0 {
+			return a.NextIds(ctx, count)
+		}
+		return b.NextIds(ctx, count)
+	})
+}
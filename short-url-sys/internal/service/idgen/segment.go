@@ -0,0 +1,58 @@
+package idgen
+
+import (
+	"context"
+
+	segmentRepo "short-url-sys/internal/repository/segment"
+)
+
+const defaultSegmentBizTag = "short_url"
+
+// SegmentGenerator 基于数据库号段的ID生成器（Leaf-segment），
+// 双缓冲：当前号段剩余比例低于阈值时异步预取下一个号段
+type SegmentGenerator struct {
+	repo      segmentRepo.Repository
+	bizTag    string
+	allocator *rangeAllocator
+}
+
+func (g *SegmentGenerator) NextId(ctx context.Context) (uint64, error) {
+	ids, err := g.allocator.take(ctx, 1)
+	if err != nil {
+		return 0, err
+	}
+	return ids[0], nil
+}
+
+func (g *SegmentGenerator) NextIds(ctx context.Context, n int) ([]uint64, error) {
+	return g.allocator.take(ctx, n)
+}
+
+func (g *SegmentGenerator) String() string {
+	return "segment"
+}
+
+func (g *SegmentGenerator) load(ctx context.Context) (*segment, error) {
+	s, err := g.repo.Allocate(ctx, g.bizTag)
+	if err != nil {
+		return nil, err
+	}
+	return &segment{
+		cursor: s.MaxID - s.Step + 1,
+		max:    s.MaxID,
+		step:   s.Step,
+	}, nil
+}
+
+// NewSegmentGenerator 创建号段ID生成器，threshold 为触发预取的剩余比例
+func NewSegmentGenerator(repo segmentRepo.Repository, bizTag string, threshold float64) *SegmentGenerator {
+	if bizTag == "" {
+		bizTag = defaultSegmentBizTag
+	}
+	g := &SegmentGenerator{
+		repo:   repo,
+		bizTag: bizTag,
+	}
+	g.allocator = newRangeAllocator("segment:"+bizTag, threshold, g.load)
+	return g
+}
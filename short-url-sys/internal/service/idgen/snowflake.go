@@ -1,29 +1,237 @@
 package idgen
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"short-url-sys/internal/config"
+	"short-url-sys/internal/pkg/database"
+	"time"
+
 	"github.com/shgang97/sys-collections/snowflake"
 )
 
+const (
+	// 默认布局中节点id占用的位数
+	defaultNodeBits = 10
+
+	defaultLeaseKeyPrefix = "short_url:snowflake_node"
+	defaultLeaseTTL       = 30 * time.Second
+)
+
 type Snowflake struct {
-	Node *snowflake.Node
+	Node  snowflake.Generator
+	lease *NodeLease
 }
 
-func (s *Snowflake) NextId() (uint64, error) {
+func (s *Snowflake) NextId(ctx context.Context) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if s.lease != nil && !s.lease.Valid() {
+		return 0, ErrLeaseLost
+	}
 	id, err := s.Node.NextId()
 	if err != nil {
-		return 0, err
+		return 0, s.wrapError(err)
 	}
 	return uint64(id), nil
 }
 
+func (s *Snowflake) NextIds(ctx context.Context, n int) ([]uint64, error) {
+	if n <= 0 {
+		return nil, ErrInvalidCount
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if s.lease != nil && !s.lease.Valid() {
+		return nil, ErrLeaseLost
+	}
+	ids, err := s.Node.NextIds(n)
+	if err != nil {
+		return nil, s.wrapError(err)
+	}
+	result := make([]uint64, len(ids))
+	for i, id := range ids {
+		result[i] = uint64(id)
+	}
+	return result, nil
+}
+
+func (s *Snowflake) wrapError(err error) error {
+	if errors.Is(err, snowflake.ErrClockBackward) {
+		return fmt.Errorf("%w: %w", ErrClockBackward, err)
+	}
+	return err
+}
+
+// MaxID 雪花ID各字段共 63 位
+func (s *Snowflake) MaxID() uint64 {
+	return math.MaxInt64
+}
+
 func (s *Snowflake) String() string {
 	return "snowflake"
 }
 
-func NewSnowflake(nodeID int64) (*Snowflake, error) {
-	node, err := snowflake.Init(nodeID)
+// Decompose 解析ID的生成时间、数据中心、节点和序列号
+func (s *Snowflake) Decompose(id uint64) snowflake.Parts {
+	return s.Node.Decompose(int64(id))
+}
+
+// LeaseValid 未启用租约或租约仍有效时返回 true
+func (s *Snowflake) LeaseValid() bool {
+	return s.lease == nil || s.lease.Valid()
+}
+
+// Close 持久化最后的时间戳并释放租用的节点ID
+func (s *Snowflake) Close() error {
+	err := s.Node.Close()
+	if s.lease == nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if leaseErr := s.lease.Release(ctx); leaseErr != nil {
+		return leaseErr
+	}
+	return err
+}
+
+func NewSnowflake(nodeID int64, opts ...snowflake.Option) (*Snowflake, error) {
+	opts = append([]snowflake.Option{snowflake.WithWorkId(nodeID)}, opts...)
+	node, err := snowflake.New(opts...)
+	if err != nil {
+		if errors.Is(err, snowflake.ErrInvalidWorkId) || errors.Is(err, snowflake.ErrInvalidDatacenterId) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidNodeID, err)
+		}
+		return nil, err
+	}
+	return &Snowflake{Node: node}, nil
+}
+
+// NewAtomicSnowflake 创建基于无锁实现的雪花算法生成器，适用于高并发场景
+func NewAtomicSnowflake(nodeID int64, opts ...snowflake.Option) (*Snowflake, error) {
+	opts = append([]snowflake.Option{snowflake.WithWorkId(nodeID)}, opts...)
+	node, err := snowflake.NewAtomic(opts...)
 	if err != nil {
+		if errors.Is(err, snowflake.ErrInvalidWorkId) || errors.Is(err, snowflake.ErrInvalidDatacenterId) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidNodeID, err)
+		}
 		return nil, err
 	}
 	return &Snowflake{Node: node}, nil
 }
+
+// NewSnowflakeFromConfig 根据配置创建雪花算法生成器，启用租约时节点ID从Redis租用
+func NewSnowflakeFromConfig(cfg *config.SnowflakeConfig, redisClient *database.RedisClient) (*Snowflake, error) {
+	opts, err := snowflakeOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeID := cfg.NodeID
+	var lease *NodeLease
+	if cfg.Lease.Enabled {
+		keyPrefix := cfg.Lease.KeyPrefix
+		if keyPrefix == "" {
+			keyPrefix = defaultLeaseKeyPrefix
+		}
+		ttl := cfg.Lease.TTL
+		if ttl <= 0 {
+			ttl = defaultLeaseTTL
+		}
+		// 不同数据中心的节点ID互不影响
+		keyPrefix = fmt.Sprintf("%s:%d", keyPrefix, cfg.DatacenterID)
+		maxNodeID := int64(1)<<workerBits(cfg) - 1
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		lease, err = AcquireNodeLease(ctx, redisClient.Client, keyPrefix, maxNodeID, ttl)
+		if err != nil {
+			return nil, err
+		}
+		nodeID = lease.NodeID()
+	}
+
+	var s *Snowflake
+	if cfg.LockFree {
+		s, err = NewAtomicSnowflake(nodeID, opts...)
+	} else {
+		s, err = NewSnowflake(nodeID, opts...)
+	}
+	if err != nil {
+		if lease != nil {
+			lease.Release(context.Background())
+		}
+		return nil, err
+	}
+	s.lease = lease
+	return s, nil
+}
+
+// workerBits 机器id实际占用的位数
+func workerBits(cfg *config.SnowflakeConfig) uint8 {
+	if cfg.WorkerBits > 0 {
+		return cfg.WorkerBits
+	}
+	return defaultNodeBits - cfg.DatacenterBits
+}
+
+// snowflakeOptions 将配置转换为 snowflake 节点选项，未配置的项使用默认值
+func snowflakeOptions(cfg *config.SnowflakeConfig) ([]snowflake.Option, error) {
+	var opts []snowflake.Option
+	// 数据中心与机器共用10位节点位，只配置数据中心位数时机器位数取剩余部分
+	if cfg.DatacenterBits > 0 {
+		if cfg.DatacenterBits >= defaultNodeBits && cfg.WorkerBits == 0 {
+			return nil, fmt.Errorf("snowflake datacenter_bits must be less than %d", defaultNodeBits)
+		}
+		opts = append(opts, snowflake.WithDatacenterBits(cfg.DatacenterBits))
+		if cfg.WorkerBits == 0 {
+			opts = append(opts, snowflake.WithWorkIdBits(defaultNodeBits-cfg.DatacenterBits))
+		}
+	}
+	if cfg.WorkerBits > 0 {
+		opts = append(opts, snowflake.WithWorkIdBits(cfg.WorkerBits))
+	}
+	opts = append(opts, snowflake.WithDatacenterId(cfg.DatacenterID))
+	if cfg.SequenceBits > 0 {
+		opts = append(opts, snowflake.WithSequenceBits(cfg.SequenceBits))
+	}
+	if cfg.RollbackBits > 0 {
+		opts = append(opts, snowflake.WithRollbackBits(cfg.RollbackBits))
+	}
+	if cfg.MaxRollback > 0 {
+		opts = append(opts, snowflake.WithMaxRollback(cfg.MaxRollback))
+	}
+	if cfg.StateFile != "" {
+		opts = append(opts,
+			snowflake.WithTimestampStore(snowflake.NewFileStore(cfg.StateFile)),
+			snowflake.WithPersistInterval(cfg.PersistInterval),
+			snowflake.WithStartupWait(cfg.StartupWait),
+		)
+	}
+
+	switch cfg.RollbackStrategy {
+	case "", "fail":
+		opts = append(opts, snowflake.WithRollbackStrategy(snowflake.RollbackFail))
+	case "wait":
+		opts = append(opts, snowflake.WithRollbackStrategy(snowflake.RollbackWait))
+	case "borrow":
+		opts = append(opts, snowflake.WithRollbackStrategy(snowflake.RollbackBorrow))
+	default:
+		return nil, fmt.Errorf("unknown snowflake rollback strategy: %s", cfg.RollbackStrategy)
+	}
+
+	switch cfg.Clock {
+	case "", "wall":
+		opts = append(opts, snowflake.WithClock(snowflake.WallClock()))
+	case "monotonic":
+		opts = append(opts, snowflake.WithClock(snowflake.MonotonicClock()))
+	default:
+		return nil, fmt.Errorf("unknown snowflake clock: %s", cfg.Clock)
+	}
+	return opts, nil
+}
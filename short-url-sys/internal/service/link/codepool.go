@@ -0,0 +1,268 @@
+package link
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"short-url-sys/internal/config"
+	"short-url-sys/internal/pkg/errors"
+	linkRepo "short-url-sys/internal/repository/link"
+	"short-url-sys/internal/service/idgen"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultCodePoolKey            = "short_url:code_pool"
+	defaultCodePoolSize           = 10000
+	defaultCodePoolRefillBatch    = 1000
+	defaultCodePoolRefillInterval = 10 * time.Second
+	codePoolRefillTimeout         = 30 * time.Second
+)
+
+// unlockScript 仅当锁仍属于自己时删除，避免超时后删除其他实例持有的锁
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// CodePool 预生成短码池，后台任务按批从发号器取ID生成短码，批量排除已占用的短码后放入 Redis 列表，
+// 创建短链时直接取出使用，无需逐个检查短码是否存在。
+// 短码入池后被自定义短码占用的极少数情况由数据库唯一约束兜底。
+// 多个实例共用同一个池，补充时通过 Redis 锁保证同一时间只有一个实例补充
+type CodePool struct {
+	client        *redis.Client
+	key           string
+	lockKey       string
+	size          int
+	lowWater      int
+	refillBatch   int
+	interval      time.Duration
+	idGenerator   idgen.Generator
+	codeGenerator *ShortCodeGenerator
+	linkRepo      linkRepo.Repository
+
+	trigger   chan struct{}
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewCodePool 创建短码池，需调用 Start 启动后台补充
+func NewCodePool(
+	client *redis.Client,
+	cfg *config.CodePoolConfig,
+	idGenerator idgen.Generator,
+	codeGenerator *ShortCodeGenerator,
+	linkRepo linkRepo.Repository,
+) *CodePool {
+	p := &CodePool{
+		client:        client,
+		key:           cfg.Key,
+		size:          cfg.Size,
+		lowWater:      cfg.LowWater,
+		refillBatch:   cfg.RefillBatch,
+		interval:      cfg.RefillInterval,
+		idGenerator:   idGenerator,
+		codeGenerator: codeGenerator,
+		linkRepo:      linkRepo,
+		trigger:       make(chan struct{}, 1),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	if p.key == "" {
+		p.key = defaultCodePoolKey
+	}
+	p.lockKey = p.key + ":refill_lock"
+	if p.size <= 0 {
+		p.size = defaultCodePoolSize
+	}
+	if p.lowWater <= 0 || p.lowWater > p.size {
+		p.lowWater = p.size / 2
+	}
+	if p.refillBatch <= 0 {
+		p.refillBatch = defaultCodePoolRefillBatch
+	}
+	if p.interval <= 0 {
+		p.interval = defaultCodePoolRefillInterval
+	}
+	return p
+}
+
+// Start 启动后台补充任务，启动时立即检查一次
+func (p *CodePool) Start() {
+	p.notify()
+	go p.run()
+}
+
+// Close 停止后台补充任务，已在池中的短码保留供下次使用
+func (p *CodePool) Close() error {
+	p.closeOnce.Do(func() {
+		close(p.stop)
+		<-p.done
+	})
+	return nil
+}
+
+// Take 从池中取出最多 n 个短码，池中不足时返回的数量少于 n，由调用方回退到实时生成
+func (p *CodePool) Take(ctx context.Context, n int) ([]string, error) {
+	codes, err := p.client.LPopCount(ctx, p.key, n).Result()
+	if err != nil && err != redis.Nil {
+		return nil, &errors.RepositoryError{Operation: "TakeCodes", Err: err}
+	}
+	p.notify()
+	return codes, nil
+}
+
+// Return 将取出但未使用的短码放回池头，优先被下次取出
+func (p *CodePool) Return(ctx context.Context, codes ...string) error {
+	if len(codes) == 0 {
+		return nil
+	}
+	values := make([]interface{}, len(codes))
+	for i, code := range codes {
+		values[i] = code
+	}
+	if err := p.client.LPush(ctx, p.key, values...).Err(); err != nil {
+		return &errors.RepositoryError{Operation: "ReturnCodes", Err: err}
+	}
+	return nil
+}
+
+// Depth 返回池中剩余的短码数量
+func (p *CodePool) Depth(ctx context.Context) (int64, error) {
+	depth, err := p.client.LLen(ctx, p.key).Result()
+	if err != nil {
+		return 0, &errors.RepositoryError{Operation: "CodePoolDepth", Err: err}
+	}
+	return depth, nil
+}
+
+// notify 通知后台任务检查水位，不阻塞调用方
+func (p *CodePool) notify() {
+	select {
+	case p.trigger <- struct{}{}:
+	default:
+	}
+}
+
+func (p *CodePool) run() {
+	defer close(p.done)
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		case <-p.trigger:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), codePoolRefillTimeout)
+		if err := p.refill(ctx); err != nil {
+			log.Printf("refill short code pool failed: %v\n", err)
+		}
+		cancel()
+	}
+}
+
+// refill 池中数量低于低水位时按批补充到目标数量，其他实例正在补充时跳过本次检查
+func (p *CodePool) refill(ctx context.Context) error {
+	unlock, ok, err := p.lock(ctx)
+	if err != nil || !ok {
+		return err
+	}
+	defer unlock()
+
+	depth, err := p.Depth(ctx)
+	if err != nil {
+		return err
+	}
+	if depth >= int64(p.lowWater) {
+		return nil
+	}
+
+	for depth < int64(p.size) {
+		select {
+		case <-p.stop:
+			return nil
+		default:
+		}
+
+		n := min(p.refillBatch, p.size-int(depth))
+		codes, err := p.generate(ctx, n)
+		if err != nil {
+			return err
+		}
+		if len(codes) == 0 {
+			// 本批短码全部被占用，等待下次检查
+			return nil
+		}
+		depth, err = p.client.RPush(ctx, p.key, codes).Result()
+		if err != nil {
+			return &errors.RepositoryError{Operation: "RefillCodes", Err: err}
+		}
+	}
+	return nil
+}
+
+// lock 以 SETNX 获取补充锁，锁的过期时间与单次补充的超时时间相同，实例崩溃后锁自动释放
+func (p *CodePool) lock(ctx context.Context) (unlock func(), ok bool, err error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, false, err
+	}
+	token := hex.EncodeToString(buf)
+	ok, err = p.client.SetNX(ctx, p.lockKey, token, codePoolRefillTimeout).Result()
+	if err != nil {
+		return nil, false, &errors.RepositoryError{Operation: "LockCodePool", Err: err}
+	}
+	if !ok {
+		return nil, false, nil
+	}
+	return func() {
+		if err := unlockScript.Run(context.Background(), p.client, []string{p.lockKey}, token).Err(); err != nil {
+			log.Printf("unlock short code pool failed: %v\n", err)
+		}
+	}, true, nil
+}
+
+// generate 生成 n 个短码并排除不可用和已被占用的短码
+func (p *CodePool) generate(ctx context.Context, n int) ([]interface{}, error) {
+	ids, err := p.idGenerator.NextIds(ctx, n)
+	if err != nil {
+		return nil, err
+	}
+	codes := make([]string, 0, len(ids))
+	for _, id := range ids {
+		code, err := p.codeGenerator.GenerateFromID(id)
+		if err != nil {
+			return nil, err
+		}
+		if p.codeGenerator.Allowed(code) {
+			codes = append(codes, code)
+		}
+	}
+
+	existing, err := p.linkRepo.ExistingCodes(ctx, codes)
+	if err != nil {
+		return nil, err
+	}
+	taken := make(map[string]bool, len(existing))
+	for _, code := range existing {
+		taken[code] = true
+	}
+
+	result := make([]interface{}, 0, len(codes))
+	for _, code := range codes {
+		if !taken[code] {
+			result = append(result, code)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,63 @@
+package link
+
+import (
+	"context"
+	"testing"
+
+	"short-url-sys/internal/config"
+	"short-url-sys/internal/testutil"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newTestCodePool(t *testing.T) (*miniredis.Miniredis, *CodePool) {
+	t.Helper()
+	mr, client := testutil.NewRedis(t)
+	return mr, NewCodePool(client, &config.CodePoolConfig{Key: "test:pool"}, nil, nil, nil)
+}
+
+func TestCodePool_ReturnedCodesAreTakenFirst(t *testing.T) {
+	mr, pool := newTestCodePool(t)
+	ctx := context.Background()
+	mr.RPush("test:pool", "a", "b", "c")
+
+	codes, err := pool.Take(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.Return(ctx, codes[1]); err != nil {
+		t.Fatal(err)
+	}
+	next, err := pool.Take(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(next) != 2 || next[0] != "b" || next[1] != "c" {
+		t.Fatalf("codes = %v, want [b c]", next)
+	}
+}
+
+func TestCodePool_RefillLockIsExclusive(t *testing.T) {
+	mr, pool := newTestCodePool(t)
+	ctx := context.Background()
+
+	unlock, ok, err := pool.lock(ctx)
+	if err != nil || !ok {
+		t.Fatalf("first lock = %v, %v, want acquired", ok, err)
+	}
+	if _, ok, err := pool.lock(ctx); err != nil || ok {
+		t.Fatalf("second lock = %v, %v, want not acquired", ok, err)
+	}
+
+	// 锁过期后被其他实例持有，释放时不能删除其他实例的锁
+	mr.Set(pool.lockKey, "other")
+	unlock()
+	if !mr.Exists(pool.lockKey) {
+		t.Fatal("unlock deleted a lock held by another instance")
+	}
+
+	mr.Del(pool.lockKey)
+	if _, ok, err := pool.lock(ctx); err != nil || !ok {
+		t.Fatalf("lock after release = %v, %v, want acquired", ok, err)
+	}
+}
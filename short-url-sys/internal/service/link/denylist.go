@@ -0,0 +1,64 @@
+package link
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Denylist 短码禁用词检查，可替换为其他实现
+type Denylist interface {
+	// Contains 短码命中禁用词时返回 true
+	Contains(code string) bool
+}
+
+// WordDenylist 基于词表的禁用词检查，短码中包含任意一个词（不区分大小写）即命中
+type WordDenylist struct {
+	words []string
+}
+
+// NewWordDenylist 创建词表禁用词检查
+func NewWordDenylist(words []string) *WordDenylist {
+	d := &WordDenylist{}
+	for _, word := range words {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word != "" {
+			d.words = append(d.words, word)
+		}
+	}
+	return d
+}
+
+// LoadWordDenylist 从文件加载词表，每行一个词，忽略空行和 # 开头的注释行
+func LoadWordDenylist(path string) (*WordDenylist, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open denylist file failed: %w", err)
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read denylist file failed: %w", err)
+	}
+	return NewWordDenylist(words), nil
+}
+
+func (d *WordDenylist) Contains(code string) bool {
+	code = strings.ToLower(code)
+	for _, word := range d.words {
+		if strings.Contains(code, word) {
+			return true
+		}
+	}
+	return false
+}
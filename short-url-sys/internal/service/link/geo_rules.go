@@ -0,0 +1,119 @@
+package link
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"short-url-sys/internal/model"
+	"short-url-sys/internal/pkg/errors"
+	"strings"
+)
+
+// GetGeoRules 获取链接的地域跳转规则
+func (s *linkService) GetGeoRules(ctx context.Context, shortCode string) (*model.GeoRulesResponse, error) {
+	link, err := s.findLink(ctx, shortCode)
+	if err != nil {
+		return nil, err
+	}
+	return geoRulesResponse(link.ShortCode, link.GeoRules), nil
+}
+
+// SetGeoRules 替换链接的全部地域跳转规则
+func (s *linkService) SetGeoRules(ctx context.Context, shortCode string, rules []model.GeoRule) (*model.GeoRulesResponse, error) {
+	link, err := s.findLink(ctx, shortCode)
+	if err != nil {
+		return nil, err
+	}
+	normalized := make(model.GeoRules, 0, len(rules))
+	for _, rule := range rules {
+		if normalized, err = s.putGeoRule(normalized, rule); err != nil {
+			return nil, err
+		}
+	}
+	return s.saveGeoRules(ctx, link, normalized)
+}
+
+// AddGeoRule 添加地域跳转规则，已有相同国家和地区的规则时替换
+func (s *linkService) AddGeoRule(ctx context.Context, shortCode string, rule model.GeoRule) (*model.GeoRulesResponse, error) {
+	link, err := s.findLink(ctx, shortCode)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := s.putGeoRule(append(model.GeoRules(nil), link.GeoRules...), rule)
+	if err != nil {
+		return nil, err
+	}
+	return s.saveGeoRules(ctx, link, rules)
+}
+
+// DeleteGeoRules 删除指定国家和地区的规则，country 为空时删除全部规则
+func (s *linkService) DeleteGeoRules(ctx context.Context, shortCode, country, region string) (*model.GeoRulesResponse, error) {
+	link, err := s.findLink(ctx, shortCode)
+	if err != nil {
+		return nil, err
+	}
+	if country == "" && region != "" {
+		return nil, &errors.ValidationError{Field: "country", Message: "required when region is set"}
+	}
+	var rules model.GeoRules
+	if country != "" {
+		for _, rule := range link.GeoRules {
+			if !sameGeoTarget(rule, country, region) {
+				rules = append(rules, rule)
+			}
+		}
+		if len(rules) == len(link.GeoRules) {
+			return nil, &errors.ValidationError{Field: "country", Message: "no matching geo rule"}
+		}
+	}
+	return s.saveGeoRules(ctx, link, rules)
+}
+
+// putGeoRule 校验并标准化规则后放入 rules，已有相同国家和地区的规则时原位替换
+func (s *linkService) putGeoRule(rules model.GeoRules, rule model.GeoRule) (model.GeoRules, error) {
+	if err := s.ValidateURL(rule.URL); err != nil {
+		return nil, err
+	}
+	url, err := s.NormalizeURL(rule.URL)
+	if err != nil {
+		return nil, err
+	}
+	rule = model.GeoRule{
+		Country: strings.ToUpper(rule.Country),
+		Region:  strings.TrimSpace(rule.Region),
+		URL:     url,
+	}
+
+	for i := range rules {
+		if sameGeoTarget(rules[i], rule.Country, rule.Region) {
+			rules[i] = rule
+			return rules, nil
+		}
+	}
+	if len(rules) >= model.MaxGeoRules {
+		return nil, &errors.ValidationError{Field: "rules", Message: fmt.Sprintf("at most %d geo rules per link", model.MaxGeoRules)}
+	}
+	return append(rules, rule), nil
+}
+
+// saveGeoRules 只更新规则列，避免覆盖并发更新的点击数，并清除缓存使重定向读取新规则
+func (s *linkService) saveGeoRules(ctx context.Context, link *model.Link, rules model.GeoRules) (*model.GeoRulesResponse, error) {
+	if err := s.linkRepo.UpdateGeoRules(ctx, link.ShortCode, rules); err != nil {
+		return nil, err
+	}
+	if err := s.cacheRepo.DeleteLink(ctx, link.ShortCode); err != nil {
+		log.Printf("An error: %v occurred while delete short url\n", err)
+	}
+	return geoRulesResponse(link.ShortCode, rules), nil
+}
+
+func sameGeoTarget(rule model.GeoRule, country, region string) bool {
+	return strings.EqualFold(rule.Country, country) && strings.EqualFold(rule.Region, region)
+}
+
+func geoRulesResponse(shortCode string, rules model.GeoRules) *model.GeoRulesResponse {
+	if rules == nil {
+		rules = model.GeoRules{}
+	}
+	return &model.GeoRulesResponse{ShortCode: shortCode, Rules: rules}
+}
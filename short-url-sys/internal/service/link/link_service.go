@@ -9,9 +9,12 @@ import (
 	linkRepo "short-url-sys/internal/repository/link"
 	statsRepo "short-url-sys/internal/repository/stats"
 	"short-url-sys/internal/service/idgen"
+	"strings"
 	"time"
 
 	"short-url-sys/internal/repository/cache"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 type linkService struct {
@@ -21,11 +24,41 @@ type linkService struct {
 	idGenerator   idgen.Generator
 	urlValidator  *URLValidator
 	codeGenerator *ShortCodeGenerator
+	codePool      *CodePool
 	baseURL       string
+	reuseExisting bool
+}
+
+const (
+	// maxSuggestions 自定义短码被占用时最多推荐的替代短码数量
+	maxSuggestions = 5
+	// minPasswordLength 访问密码最小长度，与创建请求的绑定规则一致
+	minPasswordLength = 4
+	// maxPooledCodeConflicts 短码池中的短码插入冲突时最多换码重试的次数
+	maxPooledCodeConflicts = 3
+)
+
+// reservation 预先为条目预留的短码来源，二者都为空时实时生成
+type reservation struct {
+	id   uint64 // 预留的ID，生成短码后仍需检查是否存在
+	code string // 从短码池取出的短码，入池时已排除占用
 }
 
 // CreateShortURL 创建短链接
 func (s *linkService) CreateShortURL(ctx context.Context, req *model.CreateShortRequest) (*model.Link, error) {
+	return s.createShortURL(ctx, req, reservation{})
+}
+
+// createShortURL 创建短链接，优先使用预留的短码或ID，未预留时从短码池取
+func (s *linkService) createShortURL(ctx context.Context, req *model.CreateShortRequest, reserved reservation) (*model.Link, error) {
+	// 从短码池取出但最终没有写入的短码放回池中，避免复用已有链接、校验或插入失败时丢失
+	var pooledCodeSpent bool
+	defer func() {
+		if reserved.code != "" && !pooledCodeSpent {
+			s.returnPooledCodes(ctx, reserved.code)
+		}
+	}()
+
 	longURL := req.LongURL
 	// 验证URL
 	if err := s.ValidateURL(longURL); err != nil {
@@ -38,84 +71,110 @@ func (s *linkService) CreateShortURL(ctx context.Context, req *model.CreateShort
 		return nil, err
 	}
 
-	var shortCode string
+	user := s.getUser(req.CreatedBy)
 
-	// 处理自定义短码
-	if req.CustomCode != nil {
-		shortCode = *req.CustomCode
+	if err := validateWindow(req.StartsAt, req.ExpiresAt); err != nil {
+		return nil, err
+	}
 
-		// 验证自定义短码格式
-		if err := s.codeGenerator.ValidateCustomCode(shortCode); err != nil {
+	// 复用同一创建者已有的相同目标链接，自定义短码表示需要新的短码，设置密码、开始时间、过期时间或点击上限需要新的链接，均不复用
+	if req.CustomCode == nil && req.Password == nil && req.StartsAt == nil && req.ExpiresAt == nil && req.MaxClicks == nil &&
+		s.shouldReuse(req.ReuseExisting) {
+		existing, err := s.linkRepo.FindByLongURL(ctx, normalizeURL, user)
+		if err == nil {
+			return existing, nil
+		}
+		if err != errors.ErrLinkNotFound {
 			return nil, err
 		}
+	}
+
+	var shortCode string
+	var caseInsensitive bool
+
+	if req.CustomCode == nil && reserved.code == "" && reserved.id == 0 {
+		if codes := s.takePooledCodes(ctx, 1); len(codes) > 0 {
+			reserved.code = codes[0]
+		}
+	}
 
-		// 检查短码是否已存在
-		exists, err := s.linkRepo.Exists(ctx, shortCode)
+	// 处理自定义短码
+	if req.CustomCode != nil {
+		caseInsensitive = s.caseInsensitiveCode(req.CaseInsensitive != nil && *req.CaseInsensitive)
+
+		// 验证自定义短码格式，启用校验字符时追加校验字符
+		var exists bool
+		shortCode, exists, err = s.buildCustomCode(ctx, *req.CustomCode, caseInsensitive)
 		if err != nil {
 			return nil, err
 		}
 		if exists {
 			return nil, errors.ErrShortCodeExists
 		}
+	} else if reserved.code != "" {
+		shortCode = reserved.code
 	} else {
-		// 生成唯一短码
-		var id uint64
-		var err error
-
-		// 重试机制，防止ID冲突
-		for i := 0; i < 3; i++ {
-			id, err = s.idGenerator.NextId()
-			if err != nil {
-				return nil, err
-			}
-
-			shortCode = s.codeGenerator.GenerateFromID(id)
-
-			// 检查短码是否已存在
-			exists, err := s.linkRepo.Exists(ctx, shortCode)
-			if err != nil {
-				return nil, err
-			}
-			if !exists {
-				break
-			}
+		shortCode, err = s.generateCode(ctx, reserved.id)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-			// 如果冲突，使用随机短码
-			// 不再检查冲突，发生概率极低，即使发生，数据库唯一约束最终保证数据一致性
-			if i == 2 {
-				shortCode, err = s.codeGenerator.GenerateRandomCode(8)
-				if err != nil {
-					return nil, err
-				}
-			}
+	var passwordHash string
+	if req.Password != nil && *req.Password != "" {
+		passwordHash, err = hashPassword(*req.Password)
+		if err != nil {
+			return nil, err
 		}
 	}
 
 	// 创建链接记录
 	createTime := time.Now()
-	user := s.getUser(req.CreatedBy)
 	link := &model.Link{
-		ShortCode:   shortCode,
-		LongURL:     normalizeURL,
-		ExpiresAt:   req.ExpiresAt,
-		CreatedBy:   user,
-		CreatedAt:   createTime,
-		UpdatedBy:   user,
-		UpdatedAt:   createTime,
-		Status:      model.LinkStatusActive,
-		DeleteFlag:  "N",
-		Description: s.getDescription(req.Description),
-	}
-
-	if err := s.linkRepo.Create(ctx, link); err != nil {
+		ShortCode:       shortCode,
+		CaseInsensitive: caseInsensitive,
+		LongURL:         normalizeURL,
+		LongURLHash:     model.HashLongURL(normalizeURL),
+		StartsAt:        req.StartsAt,
+		ExpiresAt:       req.ExpiresAt,
+		PasswordHash:    passwordHash,
+		MaxClicks:       s.getMaxClicks(req.MaxClicks),
+		CreatedBy:       user,
+		CreatedAt:       createTime,
+		UpdatedBy:       user,
+		UpdatedAt:       createTime,
+		Status:          model.LinkStatusActive,
+		DeleteFlag:      "N",
+		Description:     s.getDescription(req.Description),
+	}
+
+	// 入池后短码仍可能被同名的自定义短码抢占，调用方没有指定短码，冲突时换一个短码重试而不是返回冲突
+	for attempt := 0; ; attempt++ {
+		err = s.linkRepo.Create(ctx, link)
+		if err != errors.ErrShortCodeExists || reserved.code == "" || attempt == maxPooledCodeConflicts {
+			break
+		}
+		// 冲突的短码已被占用，不放回短码池；短码池取空后改为实时生成
+		reserved.code = ""
+		if codes := s.takePooledCodes(ctx, 1); len(codes) > 0 {
+			reserved.code = codes[0]
+			link.ShortCode = reserved.code
+		} else if link.ShortCode, err = s.generateCode(ctx, 0); err != nil {
+			return nil, err
+		}
+	}
+	if err != nil {
+		// 短码冲突说明短码已被占用，不能再放回短码池
+		pooledCodeSpent = err == errors.ErrShortCodeExists
 		return nil, err
 	}
+	pooledCodeSpent = true
 	// 异步预热缓存
 	go func() {
 		ctx := context.Background()
-		err = s.cacheRepo.SetShortURL(ctx, shortCode, normalizeURL)
+		err := s.cacheRepo.SetLink(ctx, link)
 		if err != nil {
-			log.Println("An error: %w occurred while set short url", err)
+			log.Printf("An error: %v occurred while set short url\n", err)
 		}
 	}()
 
@@ -125,64 +184,76 @@ func (s *linkService) CreateShortURL(ctx context.Context, req *model.CreateShort
 // GetLongURL 获取长链接（用于重定向）
 func (s *linkService) GetLongURL(ctx context.Context, shortCode string) (string, error) {
 	// 首先尝试从缓存中获取
-	longURL, err := s.cacheRepo.GetShortURL(ctx, shortCode)
-	if err == nil {
-		return longURL, nil
-	}
-
-	// 缓存未命中，从数据库获取
-	link, err := s.linkRepo.FindByShortCode(ctx, shortCode)
-	if err != nil {
-		return "", err
+	shortCode = s.codeGenerator.Canonical(shortCode)
+	link, err := s.cacheRepo.GetLink(ctx, shortCode)
+	cached := err == nil
+	if !cached {
+		// 缓存未命中，从数据库获取
+		link, err = s.findLink(ctx, shortCode)
+		if err != nil {
+			return "", err
+		}
 	}
 	// 检查链接状态
 	if !link.IsActive() {
-		if link.Status == model.LinkStatusExpired {
+		switch link.Status {
+		case model.LinkStatusExpired:
 			return "", errors.ErrLinkExpired
+		case model.LinkStatusExhausted:
+			return "", errors.ErrLinkExhausted
+		}
+		if link.NotStarted() {
+			return "", errors.ErrLinkNotStarted
 		}
 		return "", errors.ErrLinkDisabled
 	}
 
 	// 异步更新缓存
-	go func() {
-		ctx := context.Background()
-		err = s.cacheRepo.SetShortURL(ctx, shortCode, link.LongURL)
-		if err != nil {
-			log.Println("An error: %w occurred while set short url", err)
-		}
-	}()
+	if !cached {
+		go func() {
+			ctx := context.Background()
+			err := s.cacheRepo.SetLink(ctx, link)
+			if err != nil {
+				log.Printf("An error: %v occurred while set short url\n", err)
+			}
+		}()
+	}
 
 	return link.LongURL, nil
 }
 
 // GetLinkInfo 获取链接信息
 func (s *linkService) GetLinkInfo(ctx context.Context, shortCode string) (*model.LinkInfoResponse, error) {
-	link, err := s.linkRepo.FindByShortCode(ctx, shortCode)
+	link, err := s.findLink(ctx, shortCode)
 	if err != nil {
 		return nil, err
 	}
-	lastAccess, _ := s.statsRepo.GetLastAccessed(ctx, shortCode)
+	lastAccess, _ := s.statsRepo.GetLastAccessed(ctx, link.ShortCode)
 	linkInfo := &model.LinkInfoResponse{
-		ShortCode:    link.ShortCode,
-		LongURL:      link.LongURL,
-		CreatedAt:    link.CreatedAt,
-		UpdatedAt:    link.UpdatedAt,
-		ExpiresAt:    link.ExpiresAt,
-		ClickCount:   link.ClickCount,
-		LastAccessed: lastAccess,
-		Status:       string(link.Status),
-		Description:  link.Description,
+		ShortCode:         link.ShortCode,
+		LongURL:           link.LongURL,
+		CreatedAt:         link.CreatedAt,
+		UpdatedAt:         link.UpdatedAt,
+		StartsAt:          link.StartsAt,
+		ExpiresAt:         link.ExpiresAt,
+		ClickCount:        link.ClickCount,
+		MaxClicks:         link.MaxClicks,
+		LastAccessed:      lastAccess,
+		Status:            string(link.Status),
+		Description:       link.Description,
+		PasswordProtected: link.IsPasswordProtected(),
 	}
 	return linkInfo, nil
 }
 
 // UpdateLink 更新链接信息
 func (s *linkService) UpdateLink(ctx context.Context, shortCode string, req *model.UpdateLinkRequest) (*model.LinkInfoResponse, error) {
-	link, err := s.linkRepo.FindByShortCode(ctx, shortCode)
+	link, err := s.findLink(ctx, shortCode)
 	if err != nil {
 		return nil, err
 	}
-	// 更新字段
+	// 更新字段，只写入请求中修改的列
+	var columns []string
 	if req.LongURL != nil {
 		if err := s.ValidateURL(*req.LongURL); err != nil {
 			return nil, err
@@ -192,17 +263,51 @@ func (s *linkService) UpdateLink(ctx context.Context, shortCode string, req *mod
 			return nil, err
 		}
 		link.LongURL = normalizeURL
+		link.LongURLHash = model.HashLongURL(normalizeURL)
+		columns = append(columns, "long_url", "long_url_hash")
+	}
+	if req.StartsAt != nil {
+		link.StartsAt = req.StartsAt
+		columns = append(columns, "starts_at")
 	}
 	if req.ExpiresAt != nil {
 		link.ExpiresAt = req.ExpiresAt
+		columns = append(columns, "expires_at")
+	}
+	if err := validateWindow(link.StartsAt, link.ExpiresAt); err != nil {
+		return nil, err
 	}
 	if req.Status != nil {
 		link.Status = model.LinkStatus(*req.Status)
+		columns = append(columns, "status")
 	}
 	if req.Description != nil {
 		link.Description = *req.Description
+		columns = append(columns, "description")
+	}
+	if req.MaxClicks != nil {
+		link.MaxClicks = *req.MaxClicks
+		columns = append(columns, "max_clicks")
+		// 取消限制或上调上限后恢复已用完的链接，实际放行仍以 Redis 中的额度计数为准
+		if link.Status == model.LinkStatusExhausted && (link.MaxClicks == 0 || link.MaxClicks > link.ClickCount) {
+			link.Status = model.LinkStatusActive
+			columns = append(columns, "status")
+		}
+	}
+	if req.Password != nil {
+		// 空字符串取消密码，无法通过请求绑定限制最小长度
+		if *req.Password != "" && len(*req.Password) < minPasswordLength {
+			return nil, &errors.ValidationError{Field: "password", Message: fmt.Sprintf("must be at least %d characters", minPasswordLength)}
+		}
+		link.PasswordHash = ""
+		if *req.Password != "" {
+			if link.PasswordHash, err = hashPassword(*req.Password); err != nil {
+				return nil, err
+			}
+		}
+		columns = append(columns, "password_hash")
 	}
-	if err := s.linkRepo.Update(ctx, link); err != nil {
+	if err := s.linkRepo.Update(ctx, link, columns...); err != nil {
 		return nil, err
 	}
 
@@ -210,28 +315,31 @@ func (s *linkService) UpdateLink(ctx context.Context, shortCode string, req *mod
 	go func() {
 		ctx := context.Background()
 		if link.Status == model.LinkStatusActive {
-			err = s.cacheRepo.SetShortURL(ctx, shortCode, link.LongURL)
+			err := s.cacheRepo.SetLink(ctx, link)
 			if err != nil {
-				log.Println("An error: %w occurred while set short url", err)
+				log.Printf("An error: %v occurred while set short url\n", err)
 			}
 		} else {
-			err = s.cacheRepo.DeleteShortURL(ctx, shortCode)
+			err := s.cacheRepo.DeleteLink(ctx, link.ShortCode)
 			if err != nil {
-				log.Println("An error: %w occurred while delete short url", err)
+				log.Printf("An error: %v occurred while delete short url\n", err)
 			}
 		}
 	}()
-	lastAccess, _ := s.statsRepo.GetLastAccessed(ctx, shortCode)
+	lastAccess, _ := s.statsRepo.GetLastAccessed(ctx, link.ShortCode)
 	linkInfo := &model.LinkInfoResponse{
-		ShortCode:    link.ShortCode,
-		LongURL:      link.LongURL,
-		CreatedAt:    link.CreatedAt,
-		UpdatedAt:    link.UpdatedAt,
-		ExpiresAt:    link.ExpiresAt,
-		ClickCount:   link.ClickCount,
-		LastAccessed: lastAccess,
-		Status:       string(link.Status),
-		Description:  link.Description,
+		ShortCode:         link.ShortCode,
+		LongURL:           link.LongURL,
+		CreatedAt:         link.CreatedAt,
+		UpdatedAt:         link.UpdatedAt,
+		StartsAt:          link.StartsAt,
+		ExpiresAt:         link.ExpiresAt,
+		ClickCount:        link.ClickCount,
+		MaxClicks:         link.MaxClicks,
+		LastAccessed:      lastAccess,
+		Status:            string(link.Status),
+		Description:       link.Description,
+		PasswordProtected: link.IsPasswordProtected(),
 	}
 	return linkInfo, nil
 }
@@ -240,7 +348,7 @@ func (s *linkService) UpdateLink(ctx context.Context, shortCode string, req *mod
 func (s *linkService) DeleteLink(ctx context.Context, req *model.DeleteLinkRequest) error {
 	shortCode := *req.ShortCode
 	// 检查链接是否存在
-	link, err := s.linkRepo.FindByShortCode(ctx, shortCode)
+	link, err := s.findLink(ctx, shortCode)
 	if err != nil {
 		return err
 	}
@@ -253,9 +361,14 @@ func (s *linkService) DeleteLink(ctx context.Context, req *model.DeleteLinkReque
 	// 删除缓存
 	go func() {
 		ctx := context.Background()
-		err = s.cacheRepo.SetShortURL(ctx, shortCode, link.LongURL)
+		err := s.cacheRepo.DeleteLink(ctx, link.ShortCode)
 		if err != nil {
-			log.Println("An error: %w occurred while delete short url", err)
+			log.Printf("An error: %v occurred while delete short url\n", err)
+		}
+		if link.MaxClicks > 0 {
+			if err := s.cacheRepo.DeleteClickQuota(ctx, link.ShortCode); err != nil {
+				log.Printf("An error: %v occurred while delete click quota\n", err)
+			}
 		}
 	}()
 	return nil
@@ -280,15 +393,18 @@ func (s *linkService) ListLinks(ctx context.Context, req *model.ListLinksRequest
 		// TODO 这里在for循环中查询数据库了，需要优化
 		lastAccess, _ := s.statsRepo.GetLastAccessed(ctx, link.ShortCode)
 		linkInfos[i] = model.LinkInfoResponse{
-			ShortCode:    link.ShortCode,
-			LongURL:      link.LongURL,
-			CreatedAt:    link.CreatedAt,
-			UpdatedAt:    link.UpdatedAt,
-			ExpiresAt:    link.ExpiresAt,
-			ClickCount:   link.ClickCount,
-			LastAccessed: lastAccess,
-			Status:       string(link.Status),
-			Description:  link.Description,
+			ShortCode:         link.ShortCode,
+			LongURL:           link.LongURL,
+			CreatedAt:         link.CreatedAt,
+			UpdatedAt:         link.UpdatedAt,
+			StartsAt:          link.StartsAt,
+			ExpiresAt:         link.ExpiresAt,
+			ClickCount:        link.ClickCount,
+			MaxClicks:         link.MaxClicks,
+			LastAccessed:      lastAccess,
+			Status:            string(link.Status),
+			Description:       link.Description,
+			PasswordProtected: link.IsPasswordProtected(),
 		}
 	}
 
@@ -308,13 +424,41 @@ func (s *linkService) BatchCreate(ctx context.Context, req *model.BatchCreateReq
 	results := make([]model.BatchResult, 0, len(req.URLs))
 	failed := make([]model.BatchFailed, 0, len(req.URLs))
 
+	// 为没有自定义短码的条目一次性预留短码，短码池不足的部分预留ID
+	count := 0
+	for _, item := range req.URLs {
+		if item.CustomCode == nil {
+			count++
+		}
+	}
+	codes := s.takePooledCodes(ctx, count)
+	var ids []uint64
+	if rest := count - len(codes); rest > 0 {
+		var err error
+		ids, err = s.idGenerator.NextIds(ctx, rest)
+		if err != nil {
+			s.returnPooledCodes(ctx, codes...)
+			return nil, err
+		}
+	}
+
 	for _, item := range req.URLs {
 		createReq := &model.CreateShortRequest{
-			LongURL:    item.LongURL,
-			CustomCode: item.CustomCode,
+			LongURL:         item.LongURL,
+			CustomCode:      item.CustomCode,
+			CaseInsensitive: item.CaseInsensitive,
+		}
+
+		var reserved reservation
+		if item.CustomCode == nil {
+			if len(codes) > 0 {
+				reserved.code, codes = codes[0], codes[1:]
+			} else {
+				reserved.id, ids = ids[0], ids[1:]
+			}
 		}
 
-		link, err := s.CreateShortURL(ctx, createReq)
+		link, err := s.createShortURL(ctx, createReq, reserved)
 		if err != nil {
 			failed = append(failed, model.BatchFailed{
 				LongURL: item.LongURL,
@@ -345,8 +489,94 @@ func (s *linkService) NormalizeURL(url string) (string, error) {
 	return s.urlValidator.NormalizeURL(url)
 }
 
+// CheckAvailability 检查自定义短码是否可用
+func (s *linkService) CheckAvailability(ctx context.Context, code string, caseInsensitive bool) (*model.AvailabilityResponse, error) {
+	resp := &model.AvailabilityResponse{Code: code}
+	caseInsensitive = s.caseInsensitiveCode(caseInsensitive)
+	shortCode, exists, err := s.buildCustomCode(ctx, code, caseInsensitive)
+	if err != nil {
+		if _, ok := err.(*errors.BusinessError); !ok {
+			return nil, err
+		}
+		resp.Reason = err.Error()
+	} else {
+		resp.ShortCode = shortCode
+		if !exists {
+			resp.Available = true
+			return resp, nil
+		}
+		resp.Reason = errors.ErrShortCodeExists.Error()
+	}
+
+	resp.Suggestions, err = s.SuggestCodes(ctx, code, caseInsensitive)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// SuggestCodes 从候选短码中批量排除已被占用的，返回最多 maxSuggestions 个
+func (s *linkService) SuggestCodes(ctx context.Context, code string, caseInsensitive bool) ([]string, error) {
+	caseInsensitive = s.caseInsensitiveCode(caseInsensitive)
+	build := s.codeGenerator.BuildCustomCode
+	if caseInsensitive {
+		build = s.codeGenerator.BuildCaseInsensitiveCode
+	}
+	candidates := s.codeGenerator.SuggestCandidates(code)
+	shortCodes := make([]string, 0, len(candidates))
+	bodies := make(map[string]string, len(candidates))
+	for _, candidate := range candidates {
+		shortCode, err := build(candidate)
+		if err != nil {
+			continue
+		}
+		if _, ok := bodies[shortCode]; ok {
+			continue
+		}
+		shortCodes = append(shortCodes, shortCode)
+		bodies[shortCode] = candidate
+	}
+
+	var taken map[string]bool
+	if !caseInsensitive {
+		existing, err := s.linkRepo.ExistingCodes(ctx, shortCodes)
+		if err != nil {
+			return nil, err
+		}
+		taken = make(map[string]bool, len(existing))
+		for _, shortCode := range existing {
+			taken[shortCode] = true
+		}
+	}
+
+	suggestions := make([]string, 0, maxSuggestions)
+	for _, shortCode := range shortCodes {
+		if taken[shortCode] {
+			continue
+		}
+		// 任意大小写形式都不能已存在，ExistsIgnoreCase 无法批量查询，找够数量即停止
+		if caseInsensitive {
+			exists, err := s.linkRepo.ExistsIgnoreCase(ctx, shortCode)
+			if err != nil {
+				return nil, err
+			}
+			if exists {
+				continue
+			}
+		}
+		suggestions = append(suggestions, bodies[shortCode])
+		if len(suggestions) == maxSuggestions {
+			break
+		}
+	}
+	return suggestions, nil
+}
+
 type Config struct {
-	BaseURL string
+	BaseURL       string
+	CodeGenerator *ShortCodeGenerator // 为 nil 时使用不混淆的默认生成器
+	CodePool      *CodePool           // 为 nil 时不使用短码池
+	ReuseExisting bool                // 请求未指定时是否复用已有的相同目标链接
 }
 
 // NewService 创建短链服务实例
@@ -357,14 +587,121 @@ func NewService(
 	idGenerator idgen.Generator,
 	cfg Config,
 ) Service {
+	codeGenerator := cfg.CodeGenerator
+	if codeGenerator == nil {
+		codeGenerator, _ = NewShortCodeGenerator(nil)
+	}
 	return &linkService{
 		linkRepo:      linkRepo,
 		statsRepo:     statsRepo,
 		cacheRepo:     cacheRepo,
 		idGenerator:   idGenerator,
 		urlValidator:  NewURLValidator(),
-		codeGenerator: NewShortCodeGenerator(),
+		codeGenerator: codeGenerator,
+		codePool:      cfg.CodePool,
 		baseURL:       cfg.BaseURL,
+		reuseExisting: cfg.ReuseExisting,
+	}
+}
+
+// findLink 按规范化后的短码查询链接，找不到时再查询不区分大小写的链接
+func (s *linkService) findLink(ctx context.Context, shortCode string) (*model.Link, error) {
+	shortCode = s.codeGenerator.Canonical(shortCode)
+	link, err := s.linkRepo.FindByShortCode(ctx, shortCode)
+	if err == errors.ErrLinkNotFound && strings.ToLower(shortCode) != shortCode {
+		return s.linkRepo.FindCaseInsensitive(ctx, shortCode)
+	}
+	return link, err
+}
+
+// caseInsensitiveCode 单个短码是否不区分大小写，整个部署不区分大小写时短码已统一为小写，无需单独标记
+func (s *linkService) caseInsensitiveCode(requested bool) bool {
+	return requested && !s.codeGenerator.CaseInsensitive()
+}
+
+// buildCustomCode 构建自定义短码并检查是否已被占用，不区分大小写时任意大小写形式的短码都不能已存在
+func (s *linkService) buildCustomCode(ctx context.Context, code string, caseInsensitive bool) (string, bool, error) {
+	if caseInsensitive {
+		shortCode, err := s.codeGenerator.BuildCaseInsensitiveCode(code)
+		if err != nil {
+			return "", false, err
+		}
+		exists, err := s.linkRepo.ExistsIgnoreCase(ctx, shortCode)
+		return shortCode, exists, err
+	}
+	shortCode, err := s.codeGenerator.BuildCustomCode(code)
+	if err != nil {
+		return "", false, err
+	}
+	exists, err := s.linkRepo.Exists(ctx, shortCode)
+	return shortCode, exists, err
+}
+
+// generateCode 从ID生成唯一短码，reservedID 不为 0 时第一次使用预留的ID
+func (s *linkService) generateCode(ctx context.Context, reservedID uint64) (string, error) {
+	var shortCode string
+	var id uint64
+	var err error
+
+	// 重试机制，防止ID冲突
+	for i := 0; i < 3; i++ {
+		if i == 0 && reservedID != 0 {
+			id = reservedID
+		} else {
+			id, err = s.idGenerator.NextId(ctx)
+			if err != nil {
+				return "", err
+			}
+		}
+
+		shortCode, err = s.codeGenerator.GenerateFromID(id)
+		if err != nil {
+			return "", err
+		}
+
+		// 检查短码是否可用且不存在
+		if s.codeGenerator.Allowed(shortCode) {
+			exists, err := s.linkRepo.Exists(ctx, shortCode)
+			if err != nil {
+				return "", err
+			}
+			if !exists {
+				break
+			}
+		}
+
+		// 如果冲突，使用随机短码
+		// 不再检查冲突，发生概率极低，即使发生，数据库唯一约束最终保证数据一致性
+		if i == 2 {
+			shortCode, err = s.codeGenerator.GenerateRandomCode()
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+	return shortCode, nil
+}
+
+// takePooledCodes 从短码池取出最多 n 个短码，未启用短码池或取出失败时返回空，由调用方回退到实时生成
+func (s *linkService) takePooledCodes(ctx context.Context, n int) []string {
+	if s.codePool == nil || n == 0 {
+		return nil
+	}
+	codes, err := s.codePool.Take(ctx, n)
+	if err != nil {
+		log.Printf("take short codes from pool failed: %v\n", err)
+		return nil
+	}
+	return codes
+}
+
+// returnPooledCodes 将未使用的短码放回短码池，请求已取消时仍需放回，失败只记录日志
+func (s *linkService) returnPooledCodes(ctx context.Context, codes ...string) {
+	if s.codePool == nil || len(codes) == 0 {
+		return
+	}
+	if err := s.codePool.Return(context.WithoutCancel(ctx), codes...); err != nil {
+		log.Printf("return short codes to pool failed: %v\n", err)
 	}
 }
 
@@ -376,6 +713,39 @@ func (s *linkService) getUser(createdBy *string) string {
 	return *createdBy
 }
 
+// validateWindow 开始生效时间必须早于过期时间
+func validateWindow(startsAt, expiresAt *time.Time) error {
+	if startsAt != nil && expiresAt != nil && !startsAt.Before(*expiresAt) {
+		return &errors.ValidationError{Field: "starts_at", Message: "must be before expires_at"}
+	}
+	return nil
+}
+
+// hashPassword 计算访问密码的 bcrypt 哈希
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("hash link password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// shouldReuse 请求未指定时使用服务端默认配置
+func (s *linkService) shouldReuse(reuseExisting *bool) bool {
+	if reuseExisting == nil {
+		return s.reuseExisting
+	}
+	return *reuseExisting
+}
+
+// 获取最大点击次数，0 表示不限制
+func (s *linkService) getMaxClicks(maxClicks *int64) int64 {
+	if maxClicks == nil {
+		return 0
+	}
+	return *maxClicks
+}
+
 // 获取描述信息
 func (s *linkService) getDescription(description *string) string {
 	if description == nil {
@@ -0,0 +1,175 @@
+package link
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"short-url-sys/internal/config"
+	"short-url-sys/internal/model"
+	"short-url-sys/internal/pkg/errors"
+	"short-url-sys/internal/repository/cache"
+	linkRepo "short-url-sys/internal/repository/link"
+	"short-url-sys/internal/testutil"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// fakeLinkRepo 内存中的链接仓库，只实现创建短链用到的方法
+type fakeLinkRepo struct {
+	linkRepo.Repository
+	mu    sync.Mutex
+	links map[string]*model.Link
+}
+
+func newFakeLinkRepo(taken ...string) *fakeLinkRepo {
+	r := &fakeLinkRepo{links: make(map[string]*model.Link)}
+	for _, code := range taken {
+		r.links[code] = &model.Link{ShortCode: code}
+	}
+	return r
+}
+
+func (r *fakeLinkRepo) Create(ctx context.Context, link *model.Link) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.links[link.ShortCode]; ok {
+		return errors.ErrShortCodeExists
+	}
+	r.links[link.ShortCode] = link
+	return nil
+}
+
+func (r *fakeLinkRepo) Exists(ctx context.Context, shortCode string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.links[shortCode]
+	return ok, nil
+}
+
+func (r *fakeLinkRepo) ExistsIgnoreCase(ctx context.Context, shortCode string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for code := range r.links {
+		if strings.EqualFold(code, shortCode) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeLinkRepo) ExistingCodes(ctx context.Context, shortCodes []string) ([]string, error) {
+	var existing []string
+	for _, shortCode := range shortCodes {
+		if ok, _ := r.Exists(ctx, shortCode); ok {
+			existing = append(existing, shortCode)
+		}
+	}
+	return existing, nil
+}
+
+// fakeIDGenerator 依次返回递增的ID
+type fakeIDGenerator struct {
+	mu   sync.Mutex
+	next uint64
+}
+
+func (g *fakeIDGenerator) NextId(ctx context.Context) (uint64, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.next++
+	return g.next, nil
+}
+
+func (g *fakeIDGenerator) NextIds(ctx context.Context, n int) ([]uint64, error) {
+	ids := make([]uint64, n)
+	for i := range ids {
+		ids[i], _ = g.NextId(ctx)
+	}
+	return ids, nil
+}
+
+func (g *fakeIDGenerator) String() string {
+	return "fake"
+}
+
+func newTestLinkService(t *testing.T, repo *fakeLinkRepo) (*miniredis.Miniredis, *linkService) {
+	t.Helper()
+	mr, client := testutil.NewRedis(t)
+	codeGenerator, err := NewShortCodeGenerator(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idGenerator := &fakeIDGenerator{next: 1000}
+	return mr, &linkService{
+		linkRepo:      repo,
+		cacheRepo:     cache.NewRepository(client, &config.CacheConfig{TTL: 60, Prefix: "test"}, false),
+		idGenerator:   idGenerator,
+		urlValidator:  NewURLValidator(),
+		codeGenerator: codeGenerator,
+		codePool:      NewCodePool(client, &config.CodePoolConfig{Key: "test:pool"}, idGenerator, codeGenerator, repo),
+	}
+}
+
+func TestCreateShortURL_RetriesPooledCodeConflict(t *testing.T) {
+	// 入池后 taken1、taken2 被自定义短码占用
+	mr, s := newTestLinkService(t, newFakeLinkRepo("taken1", "taken2"))
+	mr.RPush("test:pool", "taken1", "taken2", "free", "spare")
+
+	link, err := s.CreateShortURL(context.Background(), &model.CreateShortRequest{LongURL: "https://example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.ShortCode != "free" {
+		t.Fatalf("short code = %q, want free", link.ShortCode)
+	}
+	// 冲突的短码不放回短码池
+	if rest, _ := mr.List("test:pool"); len(rest) != 1 || rest[0] != "spare" {
+		t.Fatalf("pool = %v, want [spare]", rest)
+	}
+}
+
+func TestCreateShortURL_FallsBackToGeneratedCode(t *testing.T) {
+	mr, s := newTestLinkService(t, newFakeLinkRepo("taken"))
+	mr.RPush("test:pool", "taken")
+
+	link, err := s.CreateShortURL(context.Background(), &model.CreateShortRequest{LongURL: "https://example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.ShortCode == "taken" || link.ShortCode == "" {
+		t.Fatalf("short code = %q, want a generated code", link.ShortCode)
+	}
+	if mr.Exists("test:pool") {
+		list, _ := mr.List("test:pool")
+		t.Fatalf("pool = %v, want empty", list)
+	}
+}
+
+func TestCheckAvailability_CaseInsensitive(t *testing.T) {
+	_, s := newTestLinkService(t, newFakeLinkRepo("Promo", "PROMO2"))
+	ctx := context.Background()
+
+	resp, err := s.CheckAvailability(ctx, "promo", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Available {
+		t.Fatalf("promo unavailable in a case-sensitive check: %+v", resp)
+	}
+
+	// 不区分大小写的短码与 Promo 冲突，推荐的短码也不能与 PROMO2 仅大小写不同
+	resp, err = s.CheckAvailability(ctx, "promo", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Available || resp.ShortCode != "promo" || len(resp.Suggestions) == 0 {
+		t.Fatalf("resp = %+v, want promo unavailable with suggestions", resp)
+	}
+	for _, suggestion := range resp.Suggestions {
+		if strings.EqualFold(suggestion, "promo2") {
+			t.Fatalf("suggestions %v include a case variant of PROMO2", resp.Suggestions)
+		}
+	}
+}
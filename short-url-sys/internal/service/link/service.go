@@ -14,6 +14,19 @@ type Service interface {
 	DeleteLink(ctx context.Context, req *model.DeleteLinkRequest) error
 	ListLinks(ctx context.Context, req *model.ListLinksRequest) (*model.ListLinksResponse, error)
 	BatchCreate(ctx context.Context, req *model.BatchCreateRequest) (*model.BatchCreateResponse, error)
+	// CheckAvailability 检查自定义短码是否可用，不可用时附带替代短码，不创建任何数据；
+	// caseInsensitive 与创建请求的 case_insensitive 含义相同，为 true 时任意大小写形式被占用都不可用
+	CheckAvailability(ctx context.Context, code string, caseInsensitive bool) (*model.AvailabilityResponse, error)
+	// SuggestCodes 返回可用的替代自定义短码
+	SuggestCodes(ctx context.Context, code string, caseInsensitive bool) ([]string, error)
+	// GetGeoRules 获取链接的地域跳转规则
+	GetGeoRules(ctx context.Context, shortCode string) (*model.GeoRulesResponse, error)
+	// SetGeoRules 替换链接的全部地域跳转规则
+	SetGeoRules(ctx context.Context, shortCode string, rules []model.GeoRule) (*model.GeoRulesResponse, error)
+	// AddGeoRule 添加地域跳转规则，已有相同国家和地区的规则时替换
+	AddGeoRule(ctx context.Context, shortCode string, rule model.GeoRule) (*model.GeoRulesResponse, error)
+	// DeleteGeoRules 删除指定国家和地区的规则，country 为空时删除全部规则
+	DeleteGeoRules(ctx context.Context, shortCode, country, region string) (*model.GeoRulesResponse, error)
 	ValidateURL(url string) error
 	NormalizeURL(url string) (string, error)
 }
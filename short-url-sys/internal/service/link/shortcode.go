@@ -2,14 +2,31 @@ package link
 
 import (
 	"crypto/rand"
+	"fmt"
 	"math/big"
 	"regexp"
+	"short-url-sys/internal/config"
+	"short-url-sys/internal/model"
 	"short-url-sys/internal/pkg/base62"
 	"short-url-sys/internal/pkg/errors"
+	"short-url-sys/internal/pkg/feistel"
+	"strconv"
+	"strings"
 )
 
 const (
-	charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	defaultObfuscationBits = 40
+	// placeholderObfuscationKey 早期示例配置中的占位密钥
+	placeholderObfuscationKey = "change-me-in-production"
+	defaultRandomLength       = 8
+	maxRandomAttempts         = 10
+)
+
+var (
+	// 推荐短码时使用的前缀、后缀和分隔符
+	suggestPrefixes   = []string{"my", "get", "go"}
+	suggestSuffixes   = []string{"now", "hq", "app", "link"}
+	suggestSeparators = []string{"-", "_"}
 )
 
 // ShortCodeGenerator 短码生成器
@@ -17,20 +34,181 @@ type ShortCodeGenerator struct {
 	customCodeRegex *regexp.Regexp
 	minCustomLength int
 	maxCustomLength int
+	encoding        *base62.Encoding
+	length          int             // 短码固定长度（不含校验字符），0 表示不填充
+	cipher          *feistel.Cipher // 未启用混淆时为 nil
+	checkChar       bool            // 是否在短码末尾追加校验字符
+	caseInsensitive bool            // 整个部署不区分大小写，短码统一使用小写
+	reserved        map[string]bool // 保留的短码，均为小写
+	denylist        Denylist        // 为 nil 时不检查禁用词
 }
 
-// NewShortCodeGenerator 创建短码生成器
-func NewShortCodeGenerator() *ShortCodeGenerator {
-	return &ShortCodeGenerator{
+// NewShortCodeGenerator 创建短码生成器，cfg 为 nil 时使用 Base62 变长短码且不启用混淆
+func NewShortCodeGenerator(cfg *config.ShortCodeConfig) (*ShortCodeGenerator, error) {
+	g := &ShortCodeGenerator{
 		customCodeRegex: regexp.MustCompile("^[A-Za-z0-9_-]+$"),
 		minCustomLength: 3,
-		maxCustomLength: 20,
+		maxCustomLength: model.ShortCodeMaxLength,
+		encoding:        base62.StdEncoding,
+		reserved:        make(map[string]bool),
+	}
+	if cfg == nil {
+		return g, nil
+	}
+
+	g.Reserve(cfg.ReservedWords...)
+	if cfg.DenylistFile != "" {
+		denylist, err := LoadWordDenylist(cfg.DenylistFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid short_code.denylist_file: %w", err)
+		}
+		g.SetDenylist(denylist)
+	}
+
+	encoding, err := alphabetEncoding(cfg.Alphabet, cfg.CaseInsensitive)
+	if err != nil {
+		return nil, fmt.Errorf("invalid short_code.alphabet: %w", err)
+	}
+	g.encoding = encoding
+	g.caseInsensitive = cfg.CaseInsensitive
+	if g.caseInsensitive && encoding.HasUpper() {
+		return nil, fmt.Errorf("invalid short_code.alphabet: case_insensitive needs an alphabet without upper case letters, e.g. base36 or no_lookalike_lower")
+	}
+
+	g.checkChar = cfg.CheckChar
+	maxLength := model.ShortCodeMaxLength
+	if g.checkChar {
+		// 校验字符占用一位
+		maxLength--
+		g.maxCustomLength = maxLength
+	}
+	if cfg.Length < 0 || cfg.Length > maxLength {
+		return nil, fmt.Errorf("invalid short_code.length %d: must be between 0 and %d", cfg.Length, maxLength)
+	}
+	g.length = cfg.Length
+
+	if cfg.Obfuscation.Enabled {
+		// 占位密钥公开可见，使用它混淆等于没有混淆
+		if cfg.Obfuscation.Key == placeholderObfuscationKey {
+			return nil, fmt.Errorf("invalid short_code.obfuscation: key must not be the placeholder %q", placeholderObfuscationKey)
+		}
+		bits := cfg.Obfuscation.Bits
+		if bits == 0 {
+			bits = defaultObfuscationBits
+		}
+		cipher, err := feistel.New([]byte(cfg.Obfuscation.Key), bits, cfg.Obfuscation.Rounds)
+		if err != nil {
+			return nil, fmt.Errorf("invalid short_code.obfuscation: %w", err)
+		}
+		g.cipher = cipher
+	}
+	return g, nil
+}
+
+// Reserve 添加保留的短码，不区分大小写
+func (g *ShortCodeGenerator) Reserve(words ...string) {
+	for _, word := range words {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			g.reserved[word] = true
+		}
+	}
+}
+
+// SetDenylist 设置禁用词检查，传入 nil 时不检查
+func (g *ShortCodeGenerator) SetDenylist(denylist Denylist) {
+	g.denylist = denylist
+}
+
+// Allowed 检查短码是否既不是保留短码也不包含禁用词，自定义短码和生成的短码都需要检查
+func (g *ShortCodeGenerator) Allowed(code string) bool {
+	if g.reserved[strings.ToLower(code)] {
+		return false
+	}
+	return g.denylist == nil || !g.denylist.Contains(code)
+}
+
+// CaseInsensitive 整个部署是否不区分大小写
+func (g *ShortCodeGenerator) CaseInsensitive() bool {
+	return g.caseInsensitive
+}
+
+// Canonical 返回短码的规范形式，不区分大小写时转为小写，查询前需先规范化
+func (g *ShortCodeGenerator) Canonical(code string) string {
+	if g.caseInsensitive {
+		return strings.ToLower(code)
+	}
+	return code
+}
+
+// alphabetEncoding 根据配置返回编码，支持 base62、no_lookalike、base36、no_lookalike_lower 或直接给出字母表，
+// 未配置时区分大小写的部署使用 base62，不区分大小写的部署使用 base36
+func alphabetEncoding(alphabet string, caseInsensitive bool) (*base62.Encoding, error) {
+	if alphabet == "" && caseInsensitive {
+		alphabet = "base36"
+	}
+	switch alphabet {
+	case "", "base62":
+		return base62.StdEncoding, nil
+	case "no_lookalike":
+		return base62.NewEncoding(base62.NoLookalikeCharacterSet)
+	case "base36":
+		return base62.NewEncoding(base62.Base36CharacterSet)
+	case "no_lookalike_lower":
+		return base62.NewEncoding(base62.NoLookalikeLowerCharacterSet)
+	default:
+		return base62.NewEncoding(alphabet)
+	}
+}
+
+// CheckCapacity 检查 [0, maxID] 范围内的ID生成的短码是否都不超过固定长度与表结构长度，
+// maxID 为 0 表示发号器没有上限（计数器类），此时只检查混淆后的取值范围
+func (g *ShortCodeGenerator) CheckCapacity(maxID uint64) error {
+	limit := g.length
+	if limit == 0 {
+		limit = g.maxCustomLength
+	}
+
+	// 混淆只置换低位，最坏情况下低位全部为 1
+	maxValue := maxID
+	if g.cipher != nil {
+		maxValue |= g.cipher.Mask()
+	}
+	if need := g.encoding.EncodedLen(maxValue); need > limit {
+		return fmt.Errorf("short codes need up to %d characters for IDs up to %d, exceeds length limit %d", need, maxValue, limit)
+	}
+	return nil
+}
+
+// GenerateFromID 从ID生成短码，启用混淆时先对ID做置换，连续的ID不会得到连续的短码；
+// 设置了固定长度时左侧用零字符填充，编码后超过固定长度时返回错误
+func (g *ShortCodeGenerator) GenerateFromID(id uint64) (string, error) {
+	if g.cipher != nil {
+		id = g.cipher.Encrypt(id)
 	}
+	code := g.encoding.EncodePadded(id, g.length)
+	if g.length > 0 && len(code) > g.length {
+		return "", fmt.Errorf("ID %d does not fit in a %d character short code", id, g.length)
+	}
+	return g.appendCheckChar(code)
 }
 
-// GenerateFromID 从ID生成短码
-func (g *ShortCodeGenerator) GenerateFromID(id uint64) string {
-	return base62.Encode(id)
+// DecodeID 从短码还原ID，仅适用于 GenerateFromID 生成的短码
+func (g *ShortCodeGenerator) DecodeID(code string) (uint64, error) {
+	code = g.Canonical(code)
+	if g.checkChar {
+		if err := g.Validate(code); err != nil {
+			return 0, err
+		}
+		code = code[:len(code)-1]
+	}
+	id, err := g.encoding.Decode(code)
+	if err != nil {
+		return 0, err
+	}
+	if g.cipher != nil {
+		id = g.cipher.Decrypt(id)
+	}
+	return id, nil
 }
 
 // ValidateCustomCode 验证自定义短码
@@ -41,18 +219,151 @@ func (g *ShortCodeGenerator) ValidateCustomCode(code string) error {
 	if !g.customCodeRegex.MatchString(code) {
 		return errors.NewBusinessError("custom code can only contain letters, numbers, hyphens and underscores")
 	}
+	if g.checkChar {
+		// 校验字符只能基于字母表计算
+		for i := 0; i < len(code); i++ {
+			if !strings.ContainsRune(g.encoding.Alphabet(), rune(code[i])) {
+				return errors.NewBusinessError("custom code can only contain characters of alphabet " + g.encoding.Alphabet())
+			}
+		}
+	}
+	if !g.Allowed(code) {
+		return errors.ErrShortCodeNotAllowed
+	}
 	return nil
 }
 
-// GenerateRandomCode 生成随机短码（用于自定义短码冲突时）
-func (g *ShortCodeGenerator) GenerateRandomCode(length int) (string, error) {
+// BuildCustomCode 验证自定义短码，启用校验字符时返回追加了校验字符的短码
+func (g *ShortCodeGenerator) BuildCustomCode(code string) (string, error) {
+	code = g.Canonical(code)
+	if err := g.ValidateCustomCode(code); err != nil {
+		return "", err
+	}
+	code, err := g.appendCheckChar(code)
+	if err != nil {
+		return "", err
+	}
+	// 追加校验字符后可能拼出禁用词
+	if !g.Allowed(code) {
+		return "", errors.ErrShortCodeNotAllowed
+	}
+	return code, nil
+}
+
+// SuggestCandidates 为已被占用的自定义短码生成候选替代短码，数字后缀、常用前后缀和分隔符组合交替排列，
+// 只返回符合自定义短码规则的候选，是否已被占用由调用方检查
+func (g *ShortCodeGenerator) SuggestCandidates(code string) []string {
+	seen := map[string]bool{strings.ToLower(code): true}
+	build := func(prefix, suffix string) string {
+		// 超长时截断原短码，保留前后缀
+		body := code
+		if n := g.maxCustomLength - len(prefix) - len(suffix); len(body) > n {
+			if n < g.minCustomLength {
+				return ""
+			}
+			body = body[:n]
+		}
+		candidate := prefix + body + suffix
+		if seen[strings.ToLower(candidate)] || g.ValidateCustomCode(candidate) != nil {
+			return ""
+		}
+		seen[strings.ToLower(candidate)] = true
+		return candidate
+	}
+
+	var digits, words, separated []string
+	for i := 2; i <= 9; i++ {
+		digits = append(digits, build("", strconv.Itoa(i)))
+	}
+	for _, suffix := range suggestSuffixes {
+		words = append(words, build("", suffix))
+	}
+	for _, prefix := range suggestPrefixes {
+		words = append(words, build(prefix, ""))
+	}
+	for _, sep := range suggestSeparators {
+		for _, suffix := range suggestSuffixes {
+			separated = append(separated, build("", sep+suffix))
+		}
+		for _, prefix := range suggestPrefixes {
+			separated = append(separated, build(prefix+sep, ""))
+		}
+	}
+
+	// 交替取各类候选，使推荐结果更多样
+	var candidates []string
+	for i := 0; i < max(len(digits), len(words), len(separated)); i++ {
+		for _, group := range [][]string{digits, words, separated} {
+			if i < len(group) && group[i] != "" {
+				candidates = append(candidates, group[i])
+			}
+		}
+	}
+	return candidates
+}
+
+// BuildCaseInsensitiveCode 验证单个不区分大小写的自定义短码，返回小写形式；
+// 校验字符可能是大写字母时无法在转为小写后校验，因此不支持
+func (g *ShortCodeGenerator) BuildCaseInsensitiveCode(code string) (string, error) {
+	if g.checkChar && g.encoding.HasUpper() {
+		return "", errors.NewBusinessError("case-insensitive custom codes need an alphabet without upper case letters when check_char is enabled")
+	}
+	return g.BuildCustomCode(strings.ToLower(code))
+}
+
+// Validate 校验短码的校验字符，未启用校验字符时总是通过，
+// 用于在查询缓存和数据库之前拦截输错的短码
+func (g *ShortCodeGenerator) Validate(code string) error {
+	code = g.Canonical(code)
+	if !g.checkChar || g.encoding.ValidCheckChar(code) {
+		return nil
+	}
+	// 单个不区分大小写的短码按小写计算校验字符，访问时可能使用任意大小写
+	if lower := strings.ToLower(code); lower != code && g.encoding.ValidCheckChar(lower) {
+		return nil
+	}
+	return errors.ErrInvalidShortCode
+}
+
+func (g *ShortCodeGenerator) appendCheckChar(code string) (string, error) {
+	if !g.checkChar {
+		return code, nil
+	}
+	c, err := g.encoding.CheckChar(code)
+	if err != nil {
+		return "", err
+	}
+	return code + string(c), nil
+}
+
+// GenerateRandomCode 生成随机短码（用于自定义短码冲突时），长度与字母表遵循短码配置，
+// 跳过保留短码和包含禁用词的短码
+func (g *ShortCodeGenerator) GenerateRandomCode() (string, error) {
+	for i := 0; i < maxRandomAttempts; i++ {
+		code, err := g.randomCode()
+		if err != nil {
+			return "", err
+		}
+		if g.Allowed(code) {
+			return code, nil
+		}
+	}
+	return "", fmt.Errorf("no allowed random short code after %d attempts", maxRandomAttempts)
+}
+
+func (g *ShortCodeGenerator) randomCode() (string, error) {
+	length := g.length
+	if length == 0 {
+		length = defaultRandomLength
+	}
+	alphabet := g.encoding.Alphabet()
 	result := make([]byte, length)
 	for i := range result {
-		num, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+		num, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
 		if err != nil {
 			return "", err
 		}
-		result[i] = charset[num.Int64()]
+		result[i] = alphabet[num.Int64()]
 	}
-	return string(result), nil
+	return g.appendCheckChar(string(result))
 }
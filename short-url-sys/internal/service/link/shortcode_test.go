@@ -0,0 +1,73 @@
+package link
+
+import (
+	"strings"
+	"testing"
+
+	"short-url-sys/internal/config"
+	"short-url-sys/internal/pkg/errors"
+)
+
+func TestShortCodeGenerator_DecodeID(t *testing.T) {
+	obfuscation := config.ObfuscationConfig{Enabled: true, Key: "test-key"}
+	tests := []struct {
+		name string
+		cfg  *config.ShortCodeConfig
+	}{
+		{"plain", nil},
+		{"obfuscated", &config.ShortCodeConfig{Obfuscation: obfuscation}},
+		{"obfuscated 62 bits", &config.ShortCodeConfig{Obfuscation: config.ObfuscationConfig{Enabled: true, Key: "test-key", Bits: 62}}},
+		{"padded with check char", &config.ShortCodeConfig{Length: 11, CheckChar: true, Obfuscation: obfuscation}},
+		{"case insensitive", &config.ShortCodeConfig{Alphabet: "base36", CaseInsensitive: true, Length: 13, Obfuscation: obfuscation}},
+	}
+	ids := []uint64{0, 1, 2, 1000, 1<<40 - 1, 1 << 40, 361368585391575048}
+	for _, tt := range tests {
+		g, err := NewShortCodeGenerator(tt.cfg)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		codes := make(map[string]bool, len(ids))
+		for _, id := range ids {
+			code, err := g.GenerateFromID(id)
+			if err != nil {
+				t.Fatalf("%s: GenerateFromID(%d): %v", tt.name, id, err)
+			}
+			if codes[code] {
+				t.Fatalf("%s: GenerateFromID(%d) = %q collides", tt.name, id, code)
+			}
+			codes[code] = true
+			got, err := g.DecodeID(code)
+			if err != nil || got != id {
+				t.Errorf("%s: DecodeID(%q) = %d, %v, want %d", tt.name, code, got, err, id)
+			}
+		}
+	}
+}
+
+func TestNewShortCodeGenerator_RejectsPlaceholderKey(t *testing.T) {
+	cfg := &config.ShortCodeConfig{Obfuscation: config.ObfuscationConfig{Enabled: true, Key: placeholderObfuscationKey}}
+	if _, err := NewShortCodeGenerator(cfg); err == nil {
+		t.Fatal("placeholder obfuscation key accepted")
+	}
+}
+
+func TestShortCodeGenerator_ValidateCaseInsensitiveCode(t *testing.T) {
+	// 区分大小写的部署中单独创建的不区分大小写短码
+	g, err := NewShortCodeGenerator(&config.ShortCodeConfig{Alphabet: "base36", CheckChar: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := g.BuildCaseInsensitiveCode("Promo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, visited := range []string{code, strings.ToUpper(code), "P" + code[1:]} {
+		if err := g.Validate(visited); err != nil {
+			t.Errorf("Validate(%q) = %v, want nil", visited, err)
+		}
+	}
+	typo := "PROMA" + strings.ToUpper(code[len(code)-1:])
+	if err := g.Validate(typo); err != errors.ErrInvalidShortCode {
+		t.Errorf("Validate(%q) = %v, want ErrInvalidShortCode", typo, err)
+	}
+}
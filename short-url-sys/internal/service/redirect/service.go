@@ -2,19 +2,31 @@ package redirect
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"log"
+	"net/url"
 	"short-url-sys/internal/model"
 	"short-url-sys/internal/pkg/errors"
+	"short-url-sys/internal/pkg/signing"
 	"short-url-sys/internal/repository/cache"
 	linkRepo "short-url-sys/internal/repository/link"
 	statsRepo "short-url-sys/internal/repository/stats"
 	"strings"
 	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	defaultAccessTokenTTL      = 30 * time.Minute
+	defaultMaxPasswordAttempts = 5
+	defaultLockoutWindow       = 15 * time.Minute
 )
 
 // Service 重定向接口
 type Service interface {
-	Redirect(ctx context.Context, shortCode string, req *RedirectRequest) (string, error)
+	Redirect(ctx context.Context, shortCode string, req *RedirectRequest) (*RedirectResult, error)
 	RecordClick(ctx context.Context, shortCode string, req *RedirectRequest) error
 }
 
@@ -26,25 +38,187 @@ type RedirectRequest struct {
 	Country   string
 	Region    string
 	City      string
+	// Password 访问密码保护链接时提交的密码
+	Password string
+	// AccessToken 之前验证密码后签发的访问凭证
+	AccessToken string
+}
+
+// RedirectResult 重定向结果
+type RedirectResult struct {
+	LongURL string
+	// ShortCode 链接实际的短码，不区分大小写的链接可能与请求的短码大小写不同
+	ShortCode string
+	// AccessToken 本次验证密码通过后签发的访问凭证，为空表示无需下发
+	AccessToken    string
+	AccessTokenTTL time.Duration
+	// Teaser 链接尚未生效，LongURL 为预告页地址，响应不应被缓存
+	Teaser bool
+}
+
+// Config 重定向服务配置
+type Config struct {
+	Signer              *signing.Signer // 签发密码保护链接的访问凭证，不能为空
+	AccessTokenTTL      time.Duration
+	MaxPasswordAttempts int           // 每个短码在 LockoutWindow 内允许的密码失败次数
+	LockoutWindow       time.Duration // 密码失败次数的统计窗口
+	TeaserURL           string        // 尚未生效的链接跳转的预告页，{code} 替换为短码，为空时返回尚未生效错误
 }
 
 type redirectService struct {
-	linkRepo  linkRepo.Repository
-	statsRepo statsRepo.Repository
-	cacheRepo *cache.Repository
+	linkRepo            linkRepo.Repository
+	statsRepo           statsRepo.Repository
+	cacheRepo           *cache.Repository
+	signer              *signing.Signer
+	accessTokenTTL      time.Duration
+	maxPasswordAttempts int
+	lockoutWindow       time.Duration
+	teaserURL           string
 }
 
 // Redirect 执行重定向
-func (s *redirectService) Redirect(ctx context.Context, shortCode string, req *RedirectRequest) (string, error) {
-	// 从缓存或数据库获取长链接
-	longURL, err := s.getLongURL(ctx, shortCode)
+func (s *redirectService) Redirect(ctx context.Context, shortCode string, req *RedirectRequest) (*RedirectResult, error) {
+	// 从缓存或数据库获取链接
+	link, err := s.getLink(ctx, shortCode)
+	if err != nil {
+		return nil, err
+	}
+
+	// 检查链接状态
+	if !link.IsActive() {
+		switch link.Status {
+		case model.LinkStatusDisabled:
+			return nil, errors.ErrLinkDisabled
+		case model.LinkStatusExhausted:
+			return nil, errors.ErrLinkExhausted
+		}
+		if link.NotStarted() {
+			return s.notStarted(link)
+		}
+		return nil, errors.ErrLinkExpired
+	}
+
+	result := &RedirectResult{
+		LongURL:   link.LongURL,
+		ShortCode: link.ShortCode,
+	}
+	// 地域规则匹配时跳转到对应地址，否则使用 LongURL
+	if target, ok := link.GeoRules.Match(req.Country, req.Region); ok {
+		result.LongURL = target
+	}
+	if link.IsPasswordProtected() {
+		token, err := s.checkPassword(ctx, link, req)
+		if err != nil {
+			return nil, err
+		}
+		if token != "" {
+			result.AccessToken = token
+			result.AccessTokenTTL = s.accessTokenTTL
+		}
+	}
+
+	// 密码验证通过后才消耗点击额度
+	if link.MaxClicks > 0 {
+		if err := s.consumeClick(ctx, link); err != nil {
+			return nil, err
+		}
+	}
+
+	// 异步记录点击统计，使用链接实际的短码
+	go s.RecordClick(context.Background(), link.ShortCode, req)
+	return result, nil
+}
+
+// consumeClick 在 Redis 中原子地消耗一次点击额度，保证并发访问时放行次数不超过 max_clicks；
+// 用完最后一次额度后将链接标记为 exhausted 并清除缓存和额度计数
+func (s *redirectService) consumeClick(ctx context.Context, link *model.Link) error {
+	used, err := s.cacheRepo.ConsumeClick(ctx, link.ShortCode, s.loadClickCount(link.ShortCode), link.MaxClicks, link.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	if used < 0 || used >= link.MaxClicks {
+		// 缓存中的链接可能仍是 active，每次发现额度用完都同步一次状态，更新条件保证幂等
+		go s.markExhausted(link.ShortCode)
+	}
+	if used < 0 {
+		return errors.ErrLinkExhausted
+	}
+	return nil
+}
+
+// loadClickCount 从数据库读取链接的点击数，用于初始化额度计数
+func (s *redirectService) loadClickCount(shortCode string) func(context.Context) (int64, error) {
+	return func(ctx context.Context) (int64, error) {
+		link, err := s.linkRepo.FindByShortCode(ctx, shortCode)
+		if err != nil {
+			return 0, err
+		}
+		return link.ClickCount, nil
+	}
+}
+
+func (s *redirectService) markExhausted(shortCode string) {
+	ctx := context.Background()
+	if err := s.linkRepo.MarkExhausted(ctx, shortCode); err != nil {
+		log.Printf("An error: %v occurred while marking link exhausted\n", err)
+		return
+	}
+	if err := s.cacheRepo.DeleteLink(ctx, shortCode); err != nil {
+		log.Printf("An error: %v occurred while delete short url\n", err)
+	}
+	// 链接已标记为 exhausted，之后的请求在检查状态时即被拒绝，不再需要额度计数；
+	// 恢复链接时以数据库中的点击数重新初始化
+	if err := s.cacheRepo.DeleteClickQuota(ctx, shortCode); err != nil {
+		log.Printf("An error: %v occurred while delete click quota\n", err)
+	}
+}
+
+// notStarted 尚未生效的链接配置了预告页时跳转到预告页，不记录点击
+func (s *redirectService) notStarted(link *model.Link) (*RedirectResult, error) {
+	if s.teaserURL == "" {
+		return nil, errors.ErrLinkNotStarted
+	}
+	return &RedirectResult{
+		LongURL:   strings.ReplaceAll(s.teaserURL, "{code}", url.PathEscape(link.ShortCode)),
+		ShortCode: link.ShortCode,
+		Teaser:    true,
+	}, nil
+}
+
+// checkPassword 校验访问凭证或密码，密码验证通过时返回新签发的访问凭证
+func (s *redirectService) checkPassword(ctx context.Context, link *model.Link, req *RedirectRequest) (string, error) {
+	subject := accessSubject(link)
+	if req.AccessToken != "" {
+		if payload, err := s.signer.Verify(req.AccessToken); err == nil && payload == subject {
+			return "", nil
+		}
+	}
+	if req.Password == "" {
+		return "", errors.ErrPasswordRequired
+	}
+
+	// 先占用一次尝试再比较密码，否则并发请求都能在计数增加前通过检查
+	ok, err := s.cacheRepo.ReservePasswordAttempt(ctx, link.ShortCode, s.maxPasswordAttempts, s.lockoutWindow)
 	if err != nil {
 		return "", err
 	}
+	if !ok {
+		return "", errors.ErrTooManyAttempts
+	}
+	if bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(req.Password)) != nil {
+		// 失败的尝试保留占用，计入失败次数
+		return "", errors.ErrPasswordInvalid
+	}
+	if err := s.cacheRepo.ReleasePasswordAttempt(context.WithoutCancel(ctx), link.ShortCode); err != nil {
+		log.Printf("An error: %v occurred while releasing password attempt\n", err)
+	}
+	return s.signer.Sign(subject, time.Now().Add(s.accessTokenTTL)), nil
+}
 
-	// 异步记录点击统计
-	go s.RecordClick(context.Background(), shortCode, req)
-	return longURL, nil
+// accessSubject 访问凭证绑定的内容，包含密码哈希的摘要，修改或取消密码后已签发的凭证随之失效
+func accessSubject(link *model.Link) string {
+	sum := sha256.Sum256([]byte(link.PasswordHash))
+	return link.ShortCode + ":" + hex.EncodeToString(sum[:8])
 }
 
 // RecordClick 记录点击统计
@@ -57,44 +231,72 @@ func NewRedirectRequest(
 	linkRepo linkRepo.Repository,
 	statsRepo statsRepo.Repository,
 	cacheRepo *cache.Repository,
+	cfg Config,
 ) Service {
-	return &redirectService{
-		linkRepo:  linkRepo,
-		statsRepo: statsRepo,
-		cacheRepo: cacheRepo,
+	s := &redirectService{
+		linkRepo:            linkRepo,
+		statsRepo:           statsRepo,
+		cacheRepo:           cacheRepo,
+		signer:              cfg.Signer,
+		accessTokenTTL:      cfg.AccessTokenTTL,
+		maxPasswordAttempts: cfg.MaxPasswordAttempts,
+		lockoutWindow:       cfg.LockoutWindow,
+		teaserURL:           cfg.TeaserURL,
+	}
+	if s.accessTokenTTL <= 0 {
+		s.accessTokenTTL = defaultAccessTokenTTL
+	}
+	if s.maxPasswordAttempts <= 0 {
+		s.maxPasswordAttempts = defaultMaxPasswordAttempts
+	}
+	if s.lockoutWindow <= 0 {
+		s.lockoutWindow = defaultLockoutWindow
+	}
+	return s
+}
+
+// getLink 按短码获取链接，精确匹配不到时再按不区分大小写的链接查询
+func (s *redirectService) getLink(ctx context.Context, shortCode string) (*model.Link, error) {
+	link, err := s.lookup(ctx, shortCode, false)
+	if err == errors.ErrLinkNotFound {
+		if lower := strings.ToLower(shortCode); lower != shortCode {
+			return s.lookup(ctx, lower, true)
+		}
 	}
+	return link, err
 }
 
-func (s *redirectService) getLongURL(ctx context.Context, shortCode string) (string, error) {
+// lookup 先查缓存再查数据库，caseInsensitive 为 true 时只接受不区分大小写的链接
+func (s *redirectService) lookup(ctx context.Context, shortCode string, caseInsensitive bool) (*model.Link, error) {
 	// 首先尝试从缓存中获取
-	longURL, err := s.cacheRepo.GetShortURL(ctx, shortCode)
+	link, err := s.cacheRepo.GetLink(ctx, shortCode)
 	if err == nil {
-		return longURL, nil
+		if caseInsensitive && !link.CaseInsensitive {
+			return nil, errors.ErrLinkNotFound
+		}
+		return link, nil
 	}
 
-	// 混存未命中，从数据库获取
-	link, err := s.linkRepo.FindByShortCode(ctx, shortCode)
-	if err != nil {
-		return "", err
+	// 缓存未命中，从数据库获取
+	if caseInsensitive {
+		link, err = s.linkRepo.FindCaseInsensitive(ctx, shortCode)
+	} else {
+		link, err = s.linkRepo.FindByShortCode(ctx, shortCode)
 	}
-
-	// 检查链接状态
-	if !link.IsActive() {
-		if link.Status == model.LinkStatusExpired {
-			return "", errors.ErrLinkExpired
-		}
+	if err != nil {
+		return nil, err
 	}
 
 	// 更新缓存
 	go func() {
 		ctx := context.Background()
-		err = s.cacheRepo.SetShortURL(ctx, shortCode, longURL)
+		err := s.cacheRepo.SetLink(ctx, link)
 		if err != nil {
-			log.Println("An error: %w occurred while set short url", err)
+			log.Printf("An error: %v occurred while set short url\n", err)
 		}
 	}()
 
-	return longURL, nil
+	return link, nil
 }
 
 func (s *redirectService) recordClick(ctx context.Context, shortCode string, req *RedirectRequest) error {
@@ -117,21 +319,21 @@ func (s *redirectService) recordClick(ctx context.Context, shortCode string, req
 	// 记录点击
 	if err := s.statsRepo.RecordClick(ctx, stats); err != nil {
 		// 记录错误，但不影响重定向
-		log.Printf("An error: %w occurred while recording short url\n", err)
+		log.Printf("An error: %v occurred while recording short url\n", err)
 		return err
 	}
 
 	// 更新点击计数
 	if err := s.linkRepo.UpdateClickCount(ctx, shortCode, 1); err != nil {
 		// 记录错误，但不影响重定向
-		log.Printf("An error: %w occurred while recording short url\n", err)
+		log.Printf("An error: %v occurred while recording short url\n", err)
 		return err
 	}
 
 	// 更新缓存中的点击计数
 	_, err := s.cacheRepo.IncrementClickCount(ctx, shortCode)
 	if err != nil {
-		log.Printf("An error: %w occurred while recording short url\n", err)
+		log.Printf("An error: %v occurred while recording short url\n", err)
 		return err
 	}
 	return nil
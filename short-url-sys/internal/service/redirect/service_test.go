@@ -0,0 +1,97 @@
+package redirect
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"short-url-sys/internal/config"
+	"short-url-sys/internal/model"
+	"short-url-sys/internal/pkg/errors"
+	"short-url-sys/internal/pkg/signing"
+	"short-url-sys/internal/repository/cache"
+	"short-url-sys/internal/testutil"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testPassword = "secret"
+
+func newTestRedirectService(t *testing.T, maxAttempts int) (*redirectService, *model.Link) {
+	t.Helper()
+	_, client := testutil.NewRedis(t)
+	signer, err := signing.NewRandom()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewRedirectRequest(nil, nil, cache.NewRepository(client, &config.CacheConfig{TTL: 60, Prefix: "test"}, false), Config{
+		Signer:              signer,
+		MaxPasswordAttempts: maxAttempts,
+		LockoutWindow:       time.Minute,
+	}).(*redirectService)
+	return s, &model.Link{ShortCode: "abc", PasswordHash: string(hash)}
+}
+
+func TestCheckPassword_ConcurrentWrongGuesses(t *testing.T) {
+	const maxAttempts = 3
+	s, link := newTestRedirectService(t, maxAttempts)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		invalid int
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.checkPassword(context.Background(), link, &RedirectRequest{Password: "wrong"})
+			switch err {
+			case errors.ErrPasswordInvalid:
+				mu.Lock()
+				invalid++
+				mu.Unlock()
+			case errors.ErrTooManyAttempts:
+			default:
+				t.Errorf("err = %v, want ErrPasswordInvalid or ErrTooManyAttempts", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 只有 maxAttempts 次错误密码真正被比较过
+	if invalid != maxAttempts {
+		t.Fatalf("%d guesses were checked, want %d", invalid, maxAttempts)
+	}
+	// 锁定期间正确密码同样被拒绝
+	if _, err := s.checkPassword(context.Background(), link, &RedirectRequest{Password: testPassword}); err != errors.ErrTooManyAttempts {
+		t.Fatalf("err = %v, want ErrTooManyAttempts", err)
+	}
+}
+
+func TestCheckPassword_SuccessReleasesAttempt(t *testing.T) {
+	s, link := newTestRedirectService(t, 2)
+	ctx := context.Background()
+
+	if _, err := s.checkPassword(ctx, link, &RedirectRequest{Password: "wrong"}); err != errors.ErrPasswordInvalid {
+		t.Fatalf("err = %v, want ErrPasswordInvalid", err)
+	}
+	// 正确密码不计入失败次数
+	for i := 0; i < 3; i++ {
+		token, err := s.checkPassword(ctx, link, &RedirectRequest{Password: testPassword})
+		if err != nil || token == "" {
+			t.Fatalf("attempt %d: token %q, err = %v", i, token, err)
+		}
+	}
+	if _, err := s.checkPassword(ctx, link, &RedirectRequest{Password: "wrong"}); err != errors.ErrPasswordInvalid {
+		t.Fatalf("err = %v, want ErrPasswordInvalid", err)
+	}
+	if _, err := s.checkPassword(ctx, link, &RedirectRequest{Password: "wrong"}); err != errors.ErrTooManyAttempts {
+		t.Fatalf("err = %v, want ErrTooManyAttempts", err)
+	}
+}
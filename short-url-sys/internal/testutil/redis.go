@@ -0,0 +1,18 @@
+// Package testutil 测试共用的辅助函数，仅供 _test.go 文件引用
+package testutil
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// NewRedis 启动一个内存 Redis 并返回连接它的客户端，测试结束时自动关闭
+func NewRedis(t testing.TB) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return mr, client
+}
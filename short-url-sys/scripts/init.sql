@@ -5,11 +5,17 @@ USE short_url;
 
 CREATE TABLE IF NOT EXISTS links (
     id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
-    short_code VARCHAR(10) NOT NULL UNIQUE,
+    short_code VARCHAR(16) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL UNIQUE,
+    case_insensitive TINYINT(1) NOT NULL DEFAULT 0,
     long_url TEXT NOT NULL,
+    long_url_hash CHAR(64) NOT NULL DEFAULT '',
+    starts_at TIMESTAMP NULL,
     expires_at TIMESTAMP NULL,
+    password_hash VARCHAR(60) NOT NULL DEFAULT '',
     click_count BIGINT UNSIGNED DEFAULT 0,
-    status ENUM('active', 'disabled', 'expired') DEFAULT 'active',
+    max_clicks BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    geo_rules JSON NULL,
+    status ENUM('active', 'disabled', 'expired', 'exhausted') DEFAULT 'active',
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     created_by VARCHAR(100),
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
@@ -19,13 +25,14 @@ CREATE TABLE IF NOT EXISTS links (
     version INT UNSIGNED DEFAULT 0,
     INDEX idx_short_code (short_code),
     INDEX idx_created_by (created_by),
+    INDEX idx_long_url_hash_created_by (long_url_hash, created_by),
     INDEX idx_status (status),
     INDEX idx_created_at (created_at)
     );
 
 CREATE TABLE IF NOT EXISTS click_stats (
     id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
-    short_code VARCHAR(10) NOT NULL,
+    short_code VARCHAR(16) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL,
     ip_address VARCHAR(45),
     user_agent TEXT,
     referer TEXT,
@@ -42,4 +49,14 @@ CREATE TABLE IF NOT EXISTS click_stats (
     version INT UNSIGNED DEFAULT 0,
     INDEX idx_short_code (short_code),
     INDEX idx_created_at (created_at)
-    );
\ No newline at end of file
+    );
+
+CREATE TABLE IF NOT EXISTS id_segments (
+    biz_tag VARCHAR(64) NOT NULL PRIMARY KEY,
+    max_id BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    step INT UNSIGNED NOT NULL DEFAULT 1000,
+    description VARCHAR(256),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+    );
+
+INSERT IGNORE INTO id_segments (biz_tag, max_id, step, description) VALUES ('short_url', 0, 1000, '短链ID号段');
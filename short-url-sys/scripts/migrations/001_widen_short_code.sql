@@ -0,0 +1,6 @@
+-- scripts/migrations/001_widen_short_code.sql
+-- 短码最大长度由 10 调整为 16，雪花ID编码后为 11 个字符
+USE short_url;
+
+ALTER TABLE links MODIFY short_code VARCHAR(16) NOT NULL;
+ALTER TABLE click_stats MODIFY short_code VARCHAR(16) NOT NULL;
@@ -0,0 +1,8 @@
+-- scripts/migrations/002_case_sensitive_short_code.sql
+-- 短码区分大小写比较（原排序规则下 AbC 与 abc 视为同一短码），并支持单个短码不区分大小写访问
+USE short_url;
+
+ALTER TABLE links
+    MODIFY short_code VARCHAR(16) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL,
+    ADD COLUMN case_insensitive TINYINT(1) NOT NULL DEFAULT 0 AFTER short_code;
+ALTER TABLE click_stats MODIFY short_code VARCHAR(16) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL;
@@ -0,0 +1,9 @@
+-- 按标准化长链接的哈希查找同一创建者已有的链接（long_url 为 TEXT，无法直接建索引）
+USE short_url;
+
+ALTER TABLE links
+    ADD COLUMN long_url_hash CHAR(64) NOT NULL DEFAULT '' AFTER long_url,
+    ADD INDEX idx_long_url_hash_created_by (long_url_hash, created_by);
+
+-- long_url 已是标准化后的链接，SHA2 输出小写十六进制，与应用计算结果一致
+UPDATE links SET long_url_hash = SHA2(long_url, 256) WHERE long_url_hash = '';
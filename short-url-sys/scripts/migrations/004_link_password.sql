@@ -0,0 +1,4 @@
+-- 密码保护链接，保存访问密码的 bcrypt 哈希
+USE short_url;
+
+ALTER TABLE links ADD COLUMN password_hash VARCHAR(60) NOT NULL DEFAULT '' AFTER expires_at;
@@ -0,0 +1,6 @@
+-- 限制点击次数的链接，达到上限后状态变为 exhausted
+USE short_url;
+
+ALTER TABLE links
+    ADD COLUMN max_clicks BIGINT UNSIGNED NOT NULL DEFAULT 0 AFTER click_count,
+    MODIFY status ENUM('active', 'disabled', 'expired', 'exhausted') DEFAULT 'active';
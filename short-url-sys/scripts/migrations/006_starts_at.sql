@@ -0,0 +1,4 @@
+-- 链接开始生效时间，之前访问返回尚未生效
+USE short_url;
+
+ALTER TABLE links ADD COLUMN starts_at TIMESTAMP NULL AFTER long_url_hash;
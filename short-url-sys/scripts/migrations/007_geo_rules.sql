@@ -0,0 +1,4 @@
+-- 按访问者地域跳转到其他地址的规则，JSON 数组，元素为 {"country","region","url"}
+USE short_url;
+
+ALTER TABLE links ADD COLUMN geo_rules JSON NULL AFTER max_clicks;
@@ -0,0 +1,12 @@
+-- 号段模式发号器（id_generator.type: segment）使用的号段表，每个业务标识一行
+USE short_url;
+
+CREATE TABLE IF NOT EXISTS id_segments (
+    biz_tag VARCHAR(64) NOT NULL PRIMARY KEY,
+    max_id BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    step INT UNSIGNED NOT NULL DEFAULT 1000,
+    description VARCHAR(256),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+    );
+
+INSERT IGNORE INTO id_segments (biz_tag, max_id, step, description) VALUES ('short_url', 0, 1000, '短链ID号段');
@@ -0,0 +1,175 @@
+package snowflake
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+/*
+无锁实现：将 时间戳-回拨序号-序列号 打包进一个 64 位状态，通过 CAS 更新
+*/
+
+// AtomicNode generates the same ids as Node without a mutex, all state lives
+// in a single atomic word updated with a CAS loop
+type AtomicNode struct {
+	state atomic.Uint64
+	// highWater 已发出的最大相对时间戳，RollbackBorrow 时 state 中的时间戳会小于它
+	highWater    atomic.Int64
+	datacenterId int64
+	workId       int64
+	layout       layout
+	strategy     RollbackStrategy
+	maxRollback  time.Duration
+	clock        Clock
+	persister    *persister
+}
+
+// NewAtomic returns a lock-free node configured by opts, it accepts the same
+// options as New
+func NewAtomic(opts ...Option) (*AtomicNode, error) {
+	o, l, err := buildOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	lastTimestamp, err := restore(o)
+	if err != nil {
+		return nil, err
+	}
+	n := &AtomicNode{
+		datacenterId: o.datacenterId,
+		workId:       o.workId,
+		layout:       l,
+		strategy:     o.strategy,
+		maxRollback:  o.maxRollback,
+		clock:        o.clock,
+	}
+	stateShift := l.rollbackBits + l.sequenceBits
+	if lastTimestamp > l.epoch {
+		n.state.Store(uint64(l.elapsed(lastTimestamp)) << stateShift)
+		n.highWater.Store(l.elapsed(lastTimestamp))
+	}
+	if n.persister, err = newPersister(o); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// Close stops extending the persisted timestamp bound, it is a no-op without
+// WithTimestampStore
+func (n *AtomicNode) Close() error {
+	if n.persister == nil {
+		return nil
+	}
+	return n.persister.close()
+}
+
+func (n *AtomicNode) NextId() (int64, error) {
+	l := n.layout
+	stateShift := l.rollbackBits + l.sequenceBits
+	for {
+		// 先读状态再读时钟，避免把其他协程刚写入的时间戳误判为回拨
+		old := n.state.Load()
+		lastTs := int64(old >> stateShift)
+		rollbackSeq := int64(old>>l.sequenceBits) & l.rollbackMax
+		sequence := int64(old) & l.sequenceMask
+
+		now := n.clock.Now()
+		ts := l.elapsed(now.UnixMilli())
+		switch {
+		case ts > lastTs:
+			sequence = 0
+			// 时钟越过已发出的最大时间戳后回拨序号归零，highWater 在 CAS 之前更新，
+			// 因此读到 state 之后再读 highWater 不会漏掉已发出的时间戳
+			if rollbackSeq != 0 && ts > n.highWater.Load() {
+				rollbackSeq = 0
+			}
+		case ts == lastTs:
+			sequence = (sequence + 1) & l.sequenceMask
+			if sequence == 0 {
+				// 序列号溢出，等待进入下一毫秒
+				n.clock.Sleep(time.UnixMilli(now.UnixMilli() + 1).Sub(now))
+				continue
+			}
+		default:
+			// 发生时钟回拨
+			offset := time.Duration(lastTs-ts) * time.Millisecond
+			switch {
+			case n.strategy == RollbackWait && offset <= n.maxRollback:
+				n.clock.Sleep(offset)
+				continue
+			case n.strategy == RollbackBorrow && rollbackSeq < l.rollbackMax:
+				rollbackSeq++
+				sequence = 0
+			default:
+				return 0, &ClockBackwardError{Offset: offset}
+			}
+		}
+		if ts < 0 || ts > l.timestampMax {
+			return 0, ErrTimestampOverflow
+		}
+		if n.persister != nil {
+			if err := n.persister.ensure(ts + l.epoch); err != nil {
+				return 0, err
+			}
+		}
+
+		n.raiseHighWater(ts)
+		state := uint64(ts)<<stateShift | uint64(rollbackSeq)<<l.sequenceBits | uint64(sequence)
+		if n.state.CompareAndSwap(old, state) {
+			return (ts << l.timestampShift) |
+				(rollbackSeq << l.rollbackShift) |
+				(n.datacenterId << l.datacenterShift) |
+				(n.workId << l.workIdShift) |
+				sequence, nil
+		}
+	}
+}
+
+/*
+raiseHighWater 将 highWater 单调推进到 ts，CAS 失败时多推进的部分只会让回拨序号晚一点归零
+*/
+func (n *AtomicNode) raiseHighWater(ts int64) {
+	for {
+		hw := n.highWater.Load()
+		if ts <= hw || n.highWater.CompareAndSwap(hw, ts) {
+			return
+		}
+	}
+}
+
+// NextIds returns count increasing ids
+func (n *AtomicNode) NextIds(count int) ([]int64, error) {
+	if count <= 0 {
+		return nil, ErrInvalidCount
+	}
+	ids := make([]int64, count)
+	for i := range ids {
+		id, err := n.NextId()
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+// DatacenterId returns the datacenter id of the node
+func (n *AtomicNode) DatacenterId() int64 {
+	return n.datacenterId
+}
+
+// WorkId returns the worker id of the node
+func (n *AtomicNode) WorkId() int64 {
+	return n.workId
+}
+
+// Decompose splits an id back into its fields
+func (n *AtomicNode) Decompose(id int64) Parts {
+	return n.layout.decompose(id)
+}
+
+// IdAtTime returns the smallest and the largest id the node's layout can
+// generate at t
+func (n *AtomicNode) IdAtTime(t time.Time) (min, max int64) {
+	return n.layout.idAtTime(t)
+}
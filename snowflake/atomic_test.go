@@ -0,0 +1,96 @@
+package snowflake
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAtomicNode_SequenceOverflow(t *testing.T) {
+	clock := newFakeClock()
+	node, err := NewAtomic(WithClock(clock), WithSequenceBits(2), WithWorkIdBits(20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2 位序列号每毫秒只能发 4 个 id，第 5 个需要等待时钟进入下一毫秒
+	var last int64
+	for i := 0; i < 5; i++ {
+		last = mustNextId(t, node)
+	}
+	if parts := node.Decompose(last); parts.Sequence != 0 || parts.Timestamp != node.layout.elapsed(clock.Now().UnixMilli()) {
+		t.Fatalf("parts = %+v, want sequence 0 in the next millisecond", parts)
+	}
+	if clock.Slept() != time.Millisecond {
+		t.Fatalf("slept %s, want 1ms", clock.Slept())
+	}
+}
+
+func TestAtomicNode_ConcurrentUnique(t *testing.T) {
+	node, err := NewAtomic(WithWorkId(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const workers, perWorker = 16, 2000
+	ids := make(chan int64, workers*perWorker)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				id, err := node.NextId()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				ids <- id
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[int64]bool, workers*perWorker)
+	for id := range ids {
+		if seen[id] {
+			t.Fatalf("duplicate id %d", id)
+		}
+		seen[id] = true
+	}
+}
+
+// 对比互斥锁实现 Node 与无锁实现 AtomicNode 在高并发下的性能：
+// go test -run '^$' -bench Parallel
+
+func BenchmarkNode_Parallel(b *testing.B) {
+	node, err := New(WithWorkId(1))
+	if err != nil {
+		b.Fatal(err)
+	}
+	benchmarkParallel(b, node)
+}
+
+func BenchmarkAtomicNode_Parallel(b *testing.B) {
+	node, err := NewAtomic(WithWorkId(1))
+	if err != nil {
+		b.Fatal(err)
+	}
+	benchmarkParallel(b, node)
+}
+
+func benchmarkParallel(b *testing.B, g Generator) {
+	for _, parallelism := range []int{1, 4, 16, 64} {
+		b.Run(fmt.Sprintf("parallelism=%d", parallelism), func(b *testing.B) {
+			// 每个 CPU 启动 parallelism 个协程竞争同一个节点
+			b.SetParallelism(parallelism)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := g.NextId(); err != nil {
+						b.Error(err)
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package snowflake
+
+import "time"
+
+/*
+时钟源，便于测试时注入以及规避系统时间跳变
+*/
+
+// Clock is the time source a Node reads timestamps from
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+type wallClock struct{}
+
+func (wallClock) Now() time.Time {
+	return time.Now()
+}
+
+func (wallClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// WallClock returns the system wall clock, the default clock of a Node
+func WallClock() Clock {
+	return wallClock{}
+}
+
+type monotonicClock struct {
+	start time.Time
+}
+
+/*
+以创建时的墙上时间为锚点，加上单调时钟流逝的时长，因此不受 NTP 等系统时间调整影响
+*/
+func (c *monotonicClock) Now() time.Time {
+	return c.start.Add(time.Since(c.start))
+}
+
+func (c *monotonicClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// MonotonicClock returns a clock anchored to the current wall time that only
+// advances with the monotonic clock, so it never observes wall-clock jumps
+func MonotonicClock() Clock {
+	return &monotonicClock{start: time.Now()}
+}
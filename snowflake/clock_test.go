@@ -0,0 +1,118 @@
+package snowflake
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNode_SequenceOverflowWaitsForNextTick(t *testing.T) {
+	clock := newFakeClock()
+	node, err := New(WithClock(clock), WithSequenceBits(2), WithWorkIdBits(20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := node.layout.elapsed(clock.Now().UnixMilli())
+	// 2 位序列号每毫秒只能发 4 个 id，时钟不动时第 5 个必须等到下一毫秒
+	for i := 0; i < 4; i++ {
+		if parts := node.Decompose(mustNextId(t, node)); parts.Timestamp != start || parts.Sequence != int64(i) {
+			t.Fatalf("parts = %+v, want timestamp %d sequence %d", parts, start, i)
+		}
+	}
+	if parts := node.Decompose(mustNextId(t, node)); parts.Timestamp != start+1 || parts.Sequence != 0 {
+		t.Fatalf("parts = %+v, want timestamp %d sequence 0", parts, start+1)
+	}
+	if clock.Slept() != time.Millisecond {
+		t.Fatalf("slept %s, want 1ms", clock.Slept())
+	}
+}
+
+func TestWallClock(t *testing.T) {
+	before := time.Now()
+	now := WallClock().Now()
+	if now.Before(before) || now.Sub(before) > time.Second {
+		t.Fatalf("WallClock().Now() = %s, want close to %s", now, before)
+	}
+}
+
+func TestMonotonicClock(t *testing.T) {
+	clock := MonotonicClock()
+	if d := clock.Now().Sub(time.Now()); d > time.Second || d < -time.Second {
+		t.Fatalf("monotonic clock is %s away from the wall clock at creation", d)
+	}
+
+	last := clock.Now()
+	for i := 0; i < 1000; i++ {
+		now := clock.Now()
+		if now.Before(last) {
+			t.Fatalf("monotonic clock went backwards from %s to %s", last, now)
+		}
+		last = now
+	}
+
+	before := clock.Now()
+	clock.Sleep(2 * time.Millisecond)
+	if d := clock.Now().Sub(before); d < 2*time.Millisecond {
+		t.Fatalf("monotonic clock advanced %s after sleeping 2ms", d)
+	}
+}
+
+// 墙上时钟回拨会被节点检测到，单调时钟只随流逝的时长前进，不会观察到回拨
+func TestClockBackwardDetectedOnlyByWallTime(t *testing.T) {
+	clock := newFakeClock()
+	node, err := New(WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustNextId(t, node)
+	clock.Step(-time.Second)
+	if _, err := node.NextId(); !errors.Is(err, ErrClockBackward) {
+		t.Fatalf("err = %v, want ErrClockBackward", err)
+	}
+
+	monotonic, err := New(WithClock(MonotonicClock()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := mustNextId(t, monotonic)
+	for i := 0; i < 10000; i++ {
+		id := mustNextId(t, monotonic)
+		if id <= last {
+			t.Fatalf("id %d is not greater than %d", id, last)
+		}
+		last = id
+	}
+}
+
+func TestInvalidEpoch(t *testing.T) {
+	clock := newFakeClock()
+	for name, epoch := range map[string]time.Time{
+		"zero":   {},
+		"future": clock.Now().Add(time.Hour),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := New(WithClock(clock), WithEpoch(epoch)); !errors.Is(err, ErrInvalidEpoch) {
+				t.Fatalf("New err = %v, want ErrInvalidEpoch", err)
+			}
+			if _, err := NewAtomic(WithClock(clock), WithEpoch(epoch)); !errors.Is(err, ErrInvalidEpoch) {
+				t.Fatalf("NewAtomic err = %v, want ErrInvalidEpoch", err)
+			}
+		})
+	}
+}
+
+func TestTimestampOverflow(t *testing.T) {
+	clock := newFakeClock()
+	// 30 位时间戳约 12 天后溢出
+	opts := []Option{WithClock(clock), WithEpoch(clock.Now()), WithTimestampBits(30), WithWorkIdBits(21)}
+	for name, g := range generators(t, opts...) {
+		t.Run(name, func(t *testing.T) {
+			mustNextId(t, g)
+			clock.Step(13 * 24 * time.Hour)
+			if _, err := g.NextId(); !errors.Is(err, ErrTimestampOverflow) {
+				t.Fatalf("err = %v, want ErrTimestampOverflow", err)
+			}
+			clock.Step(-13 * 24 * time.Hour)
+		})
+	}
+}
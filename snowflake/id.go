@@ -0,0 +1,225 @@
+package snowflake
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+/*
+ID 类型：JSON 中以字符串表示，避免超出 JavaScript 安全整数范围 (2^53) 后丢失精度；
+雪花 id 最高位恒为 0，负数不是合法的 id，所有编码和解析都拒绝负数
+*/
+
+const (
+	encodeBase32 = "ybndrfg8ejkmcpqxot1uwisza345h769" // z-base-32
+	encodeBase58 = "123456789abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ"
+	encodeBase62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	encodeBase64 = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_" // URL 安全
+)
+
+var (
+	ErrInvalidID       = errors.New("snowflake: invalid id")
+	ErrInvalidEncoding = errors.New("snowflake: invalid id encoding")
+)
+
+var (
+	base32Encoding = newEncoding(encodeBase32)
+	base58Encoding = newEncoding(encodeBase58)
+	base62Encoding = newEncoding(encodeBase62)
+	base64Encoding = newEncoding(encodeBase64)
+)
+
+// ID is a snowflake id that marshals to a JSON string and supports several
+// compact string encodings, a negative ID is invalid
+type ID int64
+
+// Int64 returns the id as an int64
+func (id ID) Int64() int64 {
+	return int64(id)
+}
+
+// String returns the decimal form of the id
+func (id ID) String() string {
+	return strconv.FormatInt(int64(id), 10)
+}
+
+// Base32 returns the z-base-32 form of the id, or "" if the id is negative
+func (id ID) Base32() string {
+	return base32Encoding.encode(id)
+}
+
+// Base58 returns the base58 (bitcoin alphabet) form of the id, or "" if the id is negative
+func (id ID) Base58() string {
+	return base58Encoding.encode(id)
+}
+
+// Base62 returns the base62 form of the id, or "" if the id is negative
+func (id ID) Base62() string {
+	return base62Encoding.encode(id)
+}
+
+// Base64 returns the URL-safe base64 form of the id, or "" if the id is negative
+func (id ID) Base64() string {
+	return base64Encoding.encode(id)
+}
+
+// ParseString parses the decimal form of an id
+func ParseString(s string) (ID, error) {
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidID, s)
+	}
+	return ID(i), nil
+}
+
+// ParseBase32 parses the z-base-32 form of an id
+func ParseBase32(s string) (ID, error) {
+	return base32Encoding.decode(s)
+}
+
+// ParseBase58 parses the base58 form of an id
+func ParseBase58(s string) (ID, error) {
+	return base58Encoding.decode(s)
+}
+
+// ParseBase62 parses the base62 form of an id
+func ParseBase62(s string) (ID, error) {
+	return base62Encoding.decode(s)
+}
+
+// ParseBase64 parses the URL-safe base64 form of an id
+func ParseBase64(s string) (ID, error) {
+	return base64Encoding.decode(s)
+}
+
+// MarshalJSON encodes the id as a JSON string
+func (id ID) MarshalJSON() ([]byte, error) {
+	if id < 0 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidID, int64(id))
+	}
+	buf := make([]byte, 0, 22)
+	buf = append(buf, '"')
+	buf = strconv.AppendInt(buf, int64(id), 10)
+	buf = append(buf, '"')
+	return buf, nil
+}
+
+// UnmarshalJSON accepts both a JSON string and a JSON number
+func (id *ID) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	}
+	parsed, err := ParseString(s)
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
+
+// Scan implements sql.Scanner
+func (id *ID) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*id = 0
+	case int64:
+		if v < 0 {
+			return fmt.Errorf("%w: %d", ErrInvalidID, v)
+		}
+		*id = ID(v)
+	case uint64:
+		// 超出 int64 范围时转换会变为负数
+		if v > math.MaxInt64 {
+			return fmt.Errorf("%w: %d overflows", ErrInvalidID, v)
+		}
+		*id = ID(v)
+	case []byte:
+		parsed, err := ParseString(string(v))
+		if err != nil {
+			return err
+		}
+		*id = parsed
+	case string:
+		parsed, err := ParseString(v)
+		if err != nil {
+			return err
+		}
+		*id = parsed
+	default:
+		return fmt.Errorf("%w: unsupported type %T", ErrInvalidID, value)
+	}
+	return nil
+}
+
+// Value implements driver.Valuer
+func (id ID) Value() (driver.Value, error) {
+	if id < 0 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidID, int64(id))
+	}
+	return int64(id), nil
+}
+
+/*
+encoding 按给定字母表进行进制转换
+*/
+type encoding struct {
+	alphabet  string
+	decodeMap [256]byte // 0xFF 表示非法字符
+}
+
+func newEncoding(alphabet string) *encoding {
+	e := &encoding{alphabet: alphabet}
+	for i := range e.decodeMap {
+		e.decodeMap[i] = 0xFF
+	}
+	for i := 0; i < len(alphabet); i++ {
+		e.decodeMap[alphabet[i]] = byte(i)
+	}
+	return e
+}
+
+func (e *encoding) encode(id ID) string {
+	if id < 0 {
+		return ""
+	}
+	if id == 0 {
+		return e.alphabet[:1]
+	}
+	num := uint64(id)
+	base := uint64(len(e.alphabet))
+	var buf [64]byte
+	i := len(buf)
+	for num > 0 {
+		i--
+		buf[i] = e.alphabet[num%base]
+		num /= base
+	}
+	return string(buf[i:])
+}
+
+func (e *encoding) decode(s string) (ID, error) {
+	if s == "" {
+		return 0, ErrInvalidEncoding
+	}
+	base := uint64(len(e.alphabet))
+	var num uint64
+	for i := 0; i < len(s); i++ {
+		digit := e.decodeMap[s[i]]
+		if digit == 0xFF {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidEncoding, s)
+		}
+		// 超出 int64 范围
+		if num > (1<<63-1-uint64(digit))/base {
+			return 0, fmt.Errorf("%w: %q overflows", ErrInvalidEncoding, s)
+		}
+		num = num*base + uint64(digit)
+	}
+	return ID(num), nil
+}
@@ -0,0 +1,145 @@
+package snowflake
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+var roundTripIDs = []ID{0, 1, 57, 1<<32 + 7, 361368585391575048, math.MaxInt64}
+
+func TestID_StringRoundTrip(t *testing.T) {
+	for _, id := range roundTripIDs {
+		got, err := ParseString(id.String())
+		if err != nil || got != id {
+			t.Errorf("ParseString(%q) = %d, %v, want %d", id.String(), got, err, id)
+		}
+	}
+}
+
+func TestID_BaseRoundTrip(t *testing.T) {
+	encodings := []struct {
+		name   string
+		encode func(ID) string
+		parse  func(string) (ID, error)
+	}{
+		{"Base32", ID.Base32, ParseBase32},
+		{"Base58", ID.Base58, ParseBase58},
+		{"Base62", ID.Base62, ParseBase62},
+		{"Base64", ID.Base64, ParseBase64},
+	}
+	for _, e := range encodings {
+		t.Run(e.name, func(t *testing.T) {
+			for _, id := range roundTripIDs {
+				s := e.encode(id)
+				got, err := e.parse(s)
+				if err != nil || got != id {
+					t.Errorf("parse(%q) = %d, %v, want %d", s, got, err, id)
+				}
+			}
+			if s := e.encode(-1); s != "" {
+				t.Errorf("encode(-1) = %q, want empty", s)
+			}
+			if _, err := e.parse(""); !errors.Is(err, ErrInvalidEncoding) {
+				t.Errorf("parse(\"\") err = %v, want ErrInvalidEncoding", err)
+			}
+		})
+	}
+}
+
+func TestID_ParseRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse func(string) (ID, error)
+		input string
+		want  error
+	}{
+		{"negative decimal", ParseString, "-1", ErrInvalidID},
+		{"decimal overflow", ParseString, "9223372036854775808", ErrInvalidID},
+		{"not a number", ParseString, "12a", ErrInvalidID},
+		{"base62 overflow", ParseBase62, "aZl8N0y58M8", ErrInvalidEncoding},
+		{"base58 invalid char", ParseBase58, "0OIl", ErrInvalidEncoding},
+		{"base64 invalid char", ParseBase64, "abc+", ErrInvalidEncoding},
+	}
+	for _, tt := range tests {
+		if _, err := tt.parse(tt.input); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestID_JSONRoundTrip(t *testing.T) {
+	for _, id := range roundTripIDs {
+		data, err := json.Marshal(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data[0] != '"' {
+			t.Errorf("Marshal(%d) = %s, want a JSON string", id, data)
+		}
+		var got ID
+		if err := json.Unmarshal(data, &got); err != nil || got != id {
+			t.Errorf("Unmarshal(%s) = %d, %v, want %d", data, got, err, id)
+		}
+	}
+
+	// 兼容数字形式
+	var got ID
+	if err := json.Unmarshal([]byte("42"), &got); err != nil || got != 42 {
+		t.Errorf("Unmarshal(42) = %d, %v, want 42", got, err)
+	}
+	if _, err := json.Marshal(ID(-1)); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("Marshal(-1) err = %v, want ErrInvalidID", err)
+	}
+	if err := json.Unmarshal([]byte(`"-1"`), &got); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("Unmarshal(\"-1\") err = %v, want ErrInvalidID", err)
+	}
+}
+
+func TestID_SQLRoundTrip(t *testing.T) {
+	for _, id := range roundTripIDs {
+		v, err := id.Value()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got ID
+		if err := got.Scan(v); err != nil || got != id {
+			t.Errorf("Scan(%v) = %d, %v, want %d", v, got, err, id)
+		}
+	}
+	if _, err := ID(-1).Value(); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("Value(-1) err = %v, want ErrInvalidID", err)
+	}
+}
+
+func TestID_Scan(t *testing.T) {
+	tests := []struct {
+		value   interface{}
+		want    ID
+		wantErr bool
+	}{
+		{nil, 0, false},
+		{int64(7), 7, false},
+		{uint64(math.MaxInt64), math.MaxInt64, false},
+		{[]byte("123"), 123, false},
+		{"456", 456, false},
+		{int64(-1), 0, true},
+		{uint64(math.MaxInt64) + 1, 0, true},
+		{"-1", 0, true},
+		{3.5, 0, true},
+	}
+	for _, tt := range tests {
+		var got ID
+		err := got.Scan(tt.value)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidID) {
+				t.Errorf("Scan(%#v) err = %v, want ErrInvalidID", tt.value, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Scan(%#v) = %d, %v, want %d", tt.value, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+/*
+节点配置项，未设置的项使用默认布局：41位时间戳-10位节点id-12位序列号
+开启回拨序号后布局为：时间戳-回拨序号-节点id-序列号
+节点id可进一步拆分为：数据中心id-机器id，数据中心位数默认为 0
+*/
+
+var (
+	ErrInvalidLayout = errors.New("snowflake: invalid bit layout")
+	ErrInvalidEpoch  = errors.New("snowflake: invalid epoch")
+)
+
+type options struct {
+	timestampBits   uint8
+	rollbackBits    uint8
+	datacenterBits  uint8
+	workIdBits      uint8
+	sequenceBits    uint8
+	epoch           time.Time
+	datacenterId    int64
+	workId          int64
+	strategy        RollbackStrategy
+	maxRollback     time.Duration
+	clock           Clock
+	store           TimestampStore
+	persistInterval time.Duration
+	startupWait     time.Duration
+}
+
+// Option configures a Node created by New
+type Option func(*options)
+
+// WithTimestampBits sets the width of the millisecond timestamp field
+func WithTimestampBits(bits uint8) Option {
+	return func(o *options) {
+		o.timestampBits = bits
+	}
+}
+
+// WithWorkIdBits sets the width of the worker id field
+func WithWorkIdBits(bits uint8) Option {
+	return func(o *options) {
+		o.workIdBits = bits
+	}
+}
+
+// WithDatacenterBits sets the width of the datacenter id field placed right
+// before the worker id, like the original Twitter layout (5 datacenter bits
+// and 5 worker bits)
+func WithDatacenterBits(bits uint8) Option {
+	return func(o *options) {
+		o.datacenterBits = bits
+	}
+}
+
+// WithDatacenterId sets the datacenter id of the node
+func WithDatacenterId(datacenterId int64) Option {
+	return func(o *options) {
+		o.datacenterId = datacenterId
+	}
+}
+
+// WithSequenceBits sets the width of the per-millisecond sequence field
+func WithSequenceBits(bits uint8) Option {
+	return func(o *options) {
+		o.sequenceBits = bits
+	}
+}
+
+// WithRollbackBits reserves bits between the timestamp and the worker id for
+// the rollback counter used by RollbackBorrow
+func WithRollbackBits(bits uint8) Option {
+	return func(o *options) {
+		o.rollbackBits = bits
+	}
+}
+
+// WithRollbackStrategy sets how the node reacts to the clock moving backwards
+func WithRollbackStrategy(strategy RollbackStrategy) Option {
+	return func(o *options) {
+		o.strategy = strategy
+	}
+}
+
+// WithMaxRollback sets the largest backwards offset RollbackWait will wait out
+func WithMaxRollback(d time.Duration) Option {
+	return func(o *options) {
+		o.maxRollback = d
+	}
+}
+
+// WithClock sets the time source of the node, it defaults to WallClock
+func WithClock(clock Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// WithTimestampStore persists an upper bound of the timestamps issued by the
+// node so that a restart with a clock behind it is detected, see FileStore
+func WithTimestampStore(store TimestampStore) Option {
+	return func(o *options) {
+		o.store = store
+	}
+}
+
+// WithPersistInterval sets how far ahead of the clock timestamps are reserved
+// in the store, the bound is extended every half interval
+func WithPersistInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.persistInterval = d
+	}
+}
+
+// WithStartupWait sets how long New waits for the clock to pass the persisted
+// timestamp, New fails with a *ClockBackwardError when it is further behind.
+// The persisted timestamp is up to the persist interval ahead of the clock, so
+// the wait is raised to at least that interval
+func WithStartupWait(d time.Duration) Option {
+	return func(o *options) {
+		o.startupWait = d
+	}
+}
+
+// WithEpoch sets the custom epoch the timestamp field is relative to
+func WithEpoch(epoch time.Time) Option {
+	return func(o *options) {
+		o.epoch = epoch
+	}
+}
+
+// WithWorkId sets the worker id of the node
+func WithWorkId(workId int64) Option {
+	return func(o *options) {
+		o.workId = workId
+	}
+}
+
+/*
+buildOptions applies opts on top of the defaults and validates the result
+*/
+func buildOptions(opts []Option) (*options, layout, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.clock == nil {
+		o.clock = WallClock()
+	}
+	if o.persistInterval <= 0 {
+		o.persistInterval = defaultPersistInterval
+	}
+	// 正常重启时持久化的上界最多超前时钟一个间隔
+	if o.store != nil && o.startupWait < o.persistInterval {
+		o.startupWait = o.persistInterval
+	}
+	l, err := newLayout(o)
+	if err != nil {
+		return nil, layout{}, err
+	}
+	if o.datacenterId < 0 || o.datacenterId > l.datacenterMax {
+		return nil, layout{}, fmt.Errorf("%w: must be between 0 and %d", ErrInvalidDatacenterId, l.datacenterMax)
+	}
+	if o.workId < 0 || o.workId > l.workIdMax {
+		return nil, layout{}, fmt.Errorf("%w: must be between 0 and %d", ErrInvalidWorkId, l.workIdMax)
+	}
+	return o, l, nil
+}
+
+func defaultOptions() *options {
+	return &options{
+		timestampBits:   timestampBits,
+		workIdBits:      workerIdBits,
+		sequenceBits:    sequenceBits,
+		epoch:           twepoch,
+		strategy:        RollbackFail,
+		maxRollback:     defaultMaxRollback,
+		clock:           WallClock(),
+		persistInterval: defaultPersistInterval,
+	}
+}
+
+/*
+layout 由配置计算出的各字段位移和掩码
+*/
+type layout struct {
+	epoch           int64
+	timestampBits   uint8
+	datacenterBits  uint8
+	workIdBits      uint8
+	sequenceBits    uint8
+	rollbackBits    uint8
+	timestampShift  uint8
+	rollbackShift   uint8
+	datacenterShift uint8
+	workIdShift     uint8
+	timestampMax    int64
+	rollbackMax     int64
+	datacenterMax   int64
+	workIdMax       int64
+	sequenceMask    int64
+}
+
+func newLayout(o *options) (layout, error) {
+	if o.timestampBits == 0 || o.workIdBits == 0 || o.sequenceBits == 0 {
+		return layout{}, fmt.Errorf("%w: every field needs at least one bit", ErrInvalidLayout)
+	}
+	total := int(o.timestampBits) + int(o.rollbackBits) + int(o.datacenterBits) + int(o.workIdBits) + int(o.sequenceBits)
+	if total != totalBits {
+		return layout{}, fmt.Errorf("%w: %d+%d+%d+%d+%d bits, want %d in total", ErrInvalidLayout,
+			o.timestampBits, o.rollbackBits, o.datacenterBits, o.workIdBits, o.sequenceBits, totalBits)
+	}
+	if o.strategy == RollbackBorrow && o.rollbackBits == 0 {
+		return layout{}, fmt.Errorf("%w: RollbackBorrow needs rollback bits", ErrInvalidLayout)
+	}
+	if o.epoch.IsZero() || o.epoch.After(o.clock.Now()) {
+		return layout{}, fmt.Errorf("%w: %s", ErrInvalidEpoch, o.epoch)
+	}
+
+	return layout{
+		epoch:           o.epoch.UnixMilli(),
+		timestampBits:   o.timestampBits,
+		datacenterBits:  o.datacenterBits,
+		workIdBits:      o.workIdBits,
+		sequenceBits:    o.sequenceBits,
+		rollbackBits:    o.rollbackBits,
+		timestampShift:  o.rollbackBits + o.datacenterBits + o.workIdBits + o.sequenceBits,
+		rollbackShift:   o.datacenterBits + o.workIdBits + o.sequenceBits,
+		datacenterShift: o.workIdBits + o.sequenceBits,
+		workIdShift:     o.sequenceBits,
+		timestampMax:    -1 ^ (-1 << o.timestampBits),
+		rollbackMax:     -1 ^ (-1 << o.rollbackBits),
+		datacenterMax:   -1 ^ (-1 << o.datacenterBits),
+		workIdMax:       -1 ^ (-1 << o.workIdBits),
+		sequenceMask:    -1 ^ (-1 << o.sequenceBits),
+	}, nil
+}
@@ -0,0 +1,56 @@
+package snowflake
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBuildOptions_Layout(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want error
+	}{
+		{"default", nil, nil},
+		{"datacenter bits", []Option{WithDatacenterBits(5), WithWorkIdBits(5), WithDatacenterId(31), WithWorkId(31)}, nil},
+		{"datacenter and rollback bits", []Option{WithRollbackBits(2), WithDatacenterBits(3), WithWorkIdBits(5), WithDatacenterId(7)}, nil},
+		{"too many bits", []Option{WithDatacenterBits(5)}, ErrInvalidLayout},
+		{"too few bits", []Option{WithDatacenterBits(2), WithWorkIdBits(5)}, ErrInvalidLayout},
+		{"no work id bits", []Option{WithDatacenterBits(10), WithWorkIdBits(0)}, ErrInvalidLayout},
+		{"datacenter id out of range", []Option{WithDatacenterBits(5), WithWorkIdBits(5), WithDatacenterId(32)}, ErrInvalidDatacenterId},
+		{"negative datacenter id", []Option{WithDatacenterBits(5), WithWorkIdBits(5), WithDatacenterId(-1)}, ErrInvalidDatacenterId},
+		{"datacenter id without bits", []Option{WithDatacenterId(1)}, ErrInvalidDatacenterId},
+		{"work id out of range", []Option{WithDatacenterBits(5), WithWorkIdBits(5), WithWorkId(32)}, ErrInvalidWorkId},
+		{"future epoch", []Option{WithEpoch(time.Now().Add(time.Hour))}, ErrInvalidEpoch},
+	}
+	for _, tt := range tests {
+		_, _, err := buildOptions(tt.opts)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestDecompose_DatacenterLayout(t *testing.T) {
+	clock := newFakeClock()
+	for name, g := range generators(t, WithClock(clock), WithDatacenterBits(5), WithWorkIdBits(5),
+		WithDatacenterId(3), WithWorkId(17)) {
+		first := mustNextId(t, g)
+		second := mustNextId(t, g)
+		for i, id := range []int64{first, second} {
+			got := g.Decompose(id)
+			if !got.Time.Equal(clock.Now()) || got.DatacenterId != 3 || got.WorkId != 17 || got.Sequence != int64(i) {
+				t.Errorf("%s: Decompose(%d) = %+v, want datacenter 3, worker 17, sequence %d", name, id, got, i)
+			}
+		}
+
+		// 机房号和机器号取最大值时不能互相覆盖
+		full := generators(t, WithClock(clock), WithDatacenterBits(5), WithWorkIdBits(5),
+			WithDatacenterId(31), WithWorkId(31))[name]
+		got := full.Decompose(mustNextId(t, full))
+		if got.DatacenterId != 31 || got.WorkId != 31 || got.Rollback != 0 {
+			t.Errorf("%s: max ids decomposed to %+v", name, got)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package snowflake
+
+import "time"
+
+/*
+id 解析：将 id 拆分回时间戳、回拨序号、数据中心id、节点id和序列号
+*/
+
+// Parts is a snowflake id split back into its fields
+type Parts struct {
+	Time         time.Time // 生成时间
+	Timestamp    int64     // 相对 epoch 的毫秒数
+	Rollback     int64
+	DatacenterId int64
+	WorkId       int64
+	Sequence     int64
+}
+
+var defaultLayout, _ = newLayout(defaultOptions())
+
+// Parse decomposes an id generated with the default layout
+func Parse(id int64) Parts {
+	return defaultLayout.decompose(id)
+}
+
+// IdAtTime returns the smallest and the largest id the default layout can
+// generate at t, it can be used for id range queries
+func IdAtTime(t time.Time) (min, max int64) {
+	return defaultLayout.idAtTime(t)
+}
+
+// Decompose splits an id generated by this node, or by any node sharing its
+// layout, back into its fields
+func (n *Node) Decompose(id int64) Parts {
+	return n.layout.decompose(id)
+}
+
+// IdAtTime returns the smallest and the largest id the node's layout can
+// generate at t
+func (n *Node) IdAtTime(t time.Time) (min, max int64) {
+	return n.layout.idAtTime(t)
+}
+
+func (l layout) decompose(id int64) Parts {
+	ts := (id >> l.timestampShift) & l.timestampMax
+	return Parts{
+		Time:         time.UnixMilli(ts + l.epoch),
+		Timestamp:    ts,
+		Rollback:     (id >> l.rollbackShift) & l.rollbackMax,
+		DatacenterId: (id >> l.datacenterShift) & l.datacenterMax,
+		WorkId:       (id >> l.workIdShift) & l.workIdMax,
+		Sequence:     id & l.sequenceMask,
+	}
+}
+
+/*
+t 早于 epoch 或超出时间戳范围时取边界值
+*/
+func (l layout) idAtTime(t time.Time) (min, max int64) {
+	ts := l.elapsed(t.UnixMilli())
+	if ts < 0 {
+		ts = 0
+	}
+	if ts > l.timestampMax {
+		ts = l.timestampMax
+	}
+	min = ts << l.timestampShift
+	max = min | (1<<l.timestampShift - 1)
+	return min, max
+}
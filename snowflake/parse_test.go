@@ -0,0 +1,70 @@
+package snowflake
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	at := twepoch.Add(1234567 * time.Millisecond)
+	tests := []struct {
+		name string
+		id   int64
+		want Parts
+	}{
+		{"zero", 0, Parts{Time: twepoch}},
+		{"fields", 1234567<<22 | 513<<12 | 4095, Parts{Time: at, Timestamp: 1234567, WorkId: 513, Sequence: 4095}},
+		{"max timestamp", (1<<41 - 1) << 22, Parts{Time: twepoch.Add((1<<41 - 1) * time.Millisecond), Timestamp: 1<<41 - 1}},
+	}
+	for _, tt := range tests {
+		got := Parse(tt.id)
+		if !got.Time.Equal(tt.want.Time) {
+			t.Errorf("%s: time = %s, want %s", tt.name, got.Time, tt.want.Time)
+		}
+		got.Time = tt.want.Time
+		if got != tt.want {
+			t.Errorf("%s: Parse(%d) = %+v, want %+v", tt.name, tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestDecompose_GeneratedID(t *testing.T) {
+	clock := newFakeClock()
+	for name, g := range generators(t, WithClock(clock), WithWorkId(17)) {
+		id := mustNextId(t, g)
+		got := g.Decompose(id)
+		if !got.Time.Equal(clock.Now()) || got.WorkId != 17 || got.Sequence != 0 {
+			t.Errorf("%s: Decompose(%d) = %+v, want time %s, worker 17, sequence 0", name, id, got, clock.Now())
+		}
+	}
+}
+
+func TestIdAtTime(t *testing.T) {
+	clock := newFakeClock()
+	node, err := New(WithClock(clock), WithWorkId(1023))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := mustNextId(t, node)
+	min, max := node.IdAtTime(clock.Now())
+	if id < min || id > max {
+		t.Fatalf("id %d outside [%d, %d]", id, min, max)
+	}
+	if Parse(min).Timestamp != Parse(max).Timestamp || Parse(max).Sequence != 1<<12-1 || Parse(max).WorkId != 1023 {
+		t.Fatalf("IdAtTime = [%+v, %+v], want the whole millisecond", Parse(min), Parse(max))
+	}
+
+	// 下一毫秒的最小 id 紧接上一毫秒的最大 id
+	nextMin, _ := IdAtTime(clock.Now().Add(time.Millisecond))
+	if nextMin != max+1 {
+		t.Fatalf("next millisecond min = %d, want %d", nextMin, max+1)
+	}
+
+	// 超出范围时取边界值
+	if min, _ := IdAtTime(twepoch.Add(-time.Hour)); min != 0 {
+		t.Fatalf("IdAtTime before epoch min = %d, want 0", min)
+	}
+	if _, max := IdAtTime(twepoch.Add(100 * 365 * 24 * time.Hour)); max != 1<<63-1 {
+		t.Fatalf("IdAtTime past the timestamp range max = %d, want MaxInt64", max)
+	}
+}
@@ -0,0 +1,67 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+/*
+时钟回拨处理策略
+*/
+
+// RollbackStrategy decides how a Node reacts when the clock moves backwards
+type RollbackStrategy int
+
+const (
+	// RollbackFail returns a *ClockBackwardError immediately
+	RollbackFail RollbackStrategy = iota
+	// RollbackWait blocks until the clock catches up, as long as the offset
+	// is within the tolerance set by WithMaxRollback
+	RollbackWait
+	// RollbackBorrow keeps issuing ids with the rolled back timestamp and a
+	// bumped rollback counter, it requires WithRollbackBits
+	RollbackBorrow
+)
+
+const defaultMaxRollback = 10 * time.Millisecond
+
+var ErrClockBackward = errors.New("snowflake: clock moved backwards")
+
+// ClockBackwardError is returned when the clock moved backwards further than
+// the configured strategy can absorb, it matches ErrClockBackward
+type ClockBackwardError struct {
+	Offset time.Duration
+}
+
+func (e *ClockBackwardError) Error() string {
+	return fmt.Sprintf("%s by %s", ErrClockBackward, e.Offset)
+}
+
+func (e *ClockBackwardError) Is(target error) bool {
+	return target == ErrClockBackward
+}
+
+/*
+rollback handles now < lastTimestamp, the caller must hold the lock
+*/
+func (n *Node) rollback(now int64) (int64, error) {
+	offset := time.Duration(n.lastTimestamp-now) * time.Millisecond
+	switch n.strategy {
+	case RollbackWait:
+		if offset > n.maxRollback {
+			break
+		}
+		n.clock.Sleep(offset)
+		return n.nextId()
+	case RollbackBorrow:
+		if n.rollbackSeq >= n.layout.rollbackMax {
+			break
+		}
+		// 切换到新的回拨序号，同一回拨序号下时间戳单调递增，因此 (时间戳, 回拨序号) 不会重复
+		n.rollbackSeq++
+		n.sequence = 0
+		return n.compose(now)
+	}
+	return 0, &ClockBackwardError{Offset: offset}
+}
@@ -0,0 +1,181 @@
+package snowflake
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock only moves when Sleep or Step is called
+type fakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	slept time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.slept += d
+}
+
+// Step moves the clock by d, a negative d simulates a clock rollback
+func (c *fakeClock) Step(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func (c *fakeClock) Slept() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.slept
+}
+
+// generators builds a Node and an AtomicNode with the same options
+func generators(t *testing.T, opts ...Option) map[string]Generator {
+	t.Helper()
+	node, err := New(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	atomicNode, err := NewAtomic(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]Generator{"Node": node, "AtomicNode": atomicNode}
+}
+
+func mustNextId(t *testing.T, g Generator) int64 {
+	t.Helper()
+	id, err := g.NextId()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestRollbackFail(t *testing.T) {
+	for name := range generators(t) {
+		t.Run(name, func(t *testing.T) {
+			clock := newFakeClock()
+			g := generators(t, WithClock(clock))[name]
+			mustNextId(t, g)
+
+			clock.Step(-5 * time.Millisecond)
+			_, err := g.NextId()
+			if !errors.Is(err, ErrClockBackward) {
+				t.Fatalf("err = %v, want ErrClockBackward", err)
+			}
+			var backward *ClockBackwardError
+			if !errors.As(err, &backward) || backward.Offset != 5*time.Millisecond {
+				t.Fatalf("err = %#v, want offset 5ms", err)
+			}
+		})
+	}
+}
+
+func TestRollbackWait(t *testing.T) {
+	for name := range generators(t) {
+		t.Run(name, func(t *testing.T) {
+			clock := newFakeClock()
+			g := generators(t, WithClock(clock), WithRollbackStrategy(RollbackWait), WithMaxRollback(10*time.Millisecond))[name]
+			last := mustNextId(t, g)
+
+			// 回拨在容忍范围内：等待时钟追上后继续发号
+			clock.Step(-5 * time.Millisecond)
+			id := mustNextId(t, g)
+			if id <= last {
+				t.Fatalf("id %d is not greater than %d after waiting", id, last)
+			}
+			if clock.Slept() < 5*time.Millisecond {
+				t.Fatalf("slept %s, want at least 5ms", clock.Slept())
+			}
+
+			// 超出容忍范围：直接返回错误
+			clock.Step(-20 * time.Millisecond)
+			if _, err := g.NextId(); !errors.Is(err, ErrClockBackward) {
+				t.Fatalf("err = %v, want ErrClockBackward", err)
+			}
+		})
+	}
+}
+
+func TestRollbackBorrow(t *testing.T) {
+	for name := range generators(t) {
+		t.Run(name, func(t *testing.T) {
+			clock := newFakeClock()
+			g := generators(t, WithClock(clock), WithRollbackStrategy(RollbackBorrow),
+				WithRollbackBits(2), WithWorkIdBits(8))[name]
+			mustNextId(t, g)
+
+			// 2 位回拨序号可以吸收 3 次回拨
+			for want := int64(1); want <= 3; want++ {
+				clock.Step(-time.Millisecond)
+				if parts := g.Decompose(mustNextId(t, g)); parts.Rollback != want {
+					t.Fatalf("rollback = %d, want %d", parts.Rollback, want)
+				}
+			}
+			clock.Step(-time.Millisecond)
+			if _, err := g.NextId(); !errors.Is(err, ErrClockBackward) {
+				t.Fatalf("err = %v, want ErrClockBackward once the rollback counter is exhausted", err)
+			}
+
+			// 时钟越过已发出的最大时间戳后回拨序号归零，之后可以再次借用
+			clock.Step(10 * time.Millisecond)
+			if parts := g.Decompose(mustNextId(t, g)); parts.Rollback != 0 {
+				t.Fatalf("rollback = %d, want 0 after the clock caught up", parts.Rollback)
+			}
+			clock.Step(-time.Millisecond)
+			if parts := g.Decompose(mustNextId(t, g)); parts.Rollback != 1 {
+				t.Fatalf("rollback = %d, want 1", parts.Rollback)
+			}
+		})
+	}
+}
+
+func TestRollbackUnique(t *testing.T) {
+	strategies := map[string][]Option{
+		"Wait":   {WithRollbackStrategy(RollbackWait), WithMaxRollback(10 * time.Millisecond)},
+		"Borrow": {WithRollbackStrategy(RollbackBorrow), WithRollbackBits(2), WithWorkIdBits(8)},
+	}
+	for strategy, opts := range strategies {
+		for name := range generators(t, opts...) {
+			t.Run(strategy+"/"+name, func(t *testing.T) {
+				clock := newFakeClock()
+				g := generators(t, append(opts, WithClock(clock))...)[name]
+
+				seen := make(map[int64]bool)
+				generate := func() {
+					for i := 0; i < 1000; i++ {
+						id := mustNextId(t, g)
+						if seen[id] {
+							t.Fatalf("duplicate id %d", id)
+						}
+						seen[id] = true
+					}
+				}
+				for step := 0; step < 3; step++ {
+					generate()
+					clock.Step(time.Millisecond)
+					generate()
+					// 回拨到已经发过号的毫秒
+					clock.Step(-2 * time.Millisecond)
+				}
+				generate()
+			})
+		}
+	}
+}
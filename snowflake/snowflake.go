@@ -2,8 +2,6 @@ package snowflake
 
 import (
 	"errors"
-	"fmt"
-	"strconv"
 	"sync"
 	"time"
 )
@@ -19,75 +17,192 @@ import (
 */
 
 const (
-	timestampBits        = 41
-	workerIdBits         = 10
-	sequenceBits         = 12
-	timestampShift       = workerIdBits + sequenceBits
-	workIdShift          = sequenceBits
-	sequenceMask         = 1<<sequenceBits - 1
-	workIdMax      int64 = -1 ^ (-1 << workerIdBits)
+	timestampBits = 41
+	workerIdBits  = 10
+	sequenceBits  = 12
+	totalBits     = timestampBits + workerIdBits + sequenceBits
 )
 
 var (
 	/*
 	   start time： 2024-01-24
 	*/
-	twepoch = time.Date(2024, 1, 24, 0, 0, 0, 0, time.UTC).UnixMilli()
+	twepoch = time.Date(2024, 1, 24, 0, 0, 0, 0, time.UTC)
 )
 
+var (
+	ErrInvalidWorkId       = errors.New("snowflake: invalid work id")
+	ErrInvalidDatacenterId = errors.New("snowflake: invalid datacenter id")
+	ErrTimestampOverflow   = errors.New("snowflake: timestamp overflow")
+	ErrInvalidCount        = errors.New("snowflake: count must be positive")
+)
+
+// Generator is implemented by both Node and AtomicNode
+type Generator interface {
+	NextId() (int64, error)
+	NextIds(count int) ([]int64, error)
+	Decompose(id int64) Parts
+	IdAtTime(t time.Time) (min, max int64)
+	DatacenterId() int64
+	WorkId() int64
+	Close() error
+}
+
 type Node struct {
 	lastTimestamp int64
+	maxTimestamp  int64 // 已发出的最大时间戳，RollbackBorrow 时 lastTimestamp 会小于它
+	datacenterId  int64
 	workId        int64
 	sequence      int64
+	rollbackSeq   int64
+	layout        layout
+	strategy      RollbackStrategy
+	maxRollback   time.Duration
+	clock         Clock
+	persister     *persister
 
 	lock sync.Mutex
 }
 
 // Init returns a new snowflake node that can be used to generate snowflake
 func Init(workId int64) (*Node, error) {
-	n := Node{}
-	n.workId = workId
-	if n.workId < 0 || n.workId > workIdMax {
-		return nil, errors.New("Node number must be between 0 and " + strconv.FormatInt(workIdMax, 10))
+	return New(WithWorkId(workId))
+}
+
+// New returns a new snowflake node configured by opts, fields that are not
+// set fall back to the default 41/10/12 layout and the 2024-01-24 epoch
+func New(opts ...Option) (*Node, error) {
+	o, l, err := buildOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	lastTimestamp, err := restore(o)
+	if err != nil {
+		return nil, err
+	}
+	n := &Node{
+		lastTimestamp: lastTimestamp,
+		maxTimestamp:  lastTimestamp,
+		datacenterId:  o.datacenterId,
+		workId:        o.workId,
+		layout:        l,
+		strategy:      o.strategy,
+		maxRollback:   o.maxRollback,
+		clock:         o.clock,
+	}
+	if n.persister, err = newPersister(o); err != nil {
+		return nil, err
 	}
-	return &n, nil
+	return n, nil
 }
 
-func (n *Node) NextId() (id int64, err error) {
+// Close stops extending the persisted timestamp bound, it is a no-op without
+// WithTimestampStore
+func (n *Node) Close() error {
+	if n.persister == nil {
+		return nil
+	}
+	return n.persister.close()
+}
+
+func (n *Node) NextId() (int64, error) {
 	n.lock.Lock()
 	defer n.lock.Unlock()
-	now := time.Now().UnixMilli()
+	return n.nextId()
+}
+
+// NextIds reserves count ids while holding the lock only once, the ids are
+// increasing and are returned in the order they were generated
+func (n *Node) NextIds(count int) ([]int64, error) {
+	if count <= 0 {
+		return nil, ErrInvalidCount
+	}
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	ids := make([]int64, count)
+	for i := range ids {
+		id, err := n.nextId()
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+func (n *Node) nextId() (int64, error) {
+	now := n.clock.Now().UnixMilli()
 	// 发生时钟回拨
 	if now < n.lastTimestamp {
-		return id, fmt.Errorf("clock callback occurs")
+		return n.rollback(now)
 	}
 
 	if now == n.lastTimestamp {
-		n.sequence = (n.sequence + 1) & sequenceMask
+		n.sequence = (n.sequence + 1) & n.layout.sequenceMask
 		if n.sequence == 0 {
 			// 并发量太高，序列号溢出
-			now = wait(n.lastTimestamp)
+			now = n.wait(n.lastTimestamp)
 		}
 	} else {
 		n.sequence = 0
+		// 时钟越过已发出的最大时间戳后，任何回拨序号下都没有发过该时间戳，回拨序号可以归零
+		if now > n.maxTimestamp {
+			n.rollbackSeq = 0
+		}
+	}
+	return n.compose(now)
+}
+
+/*
+compose packs the current state into an id and records now as lastTimestamp
+*/
+func (n *Node) compose(now int64) (int64, error) {
+	ts := n.layout.elapsed(now)
+	if ts < 0 || ts > n.layout.timestampMax {
+		return 0, ErrTimestampOverflow
+	}
+	if n.persister != nil {
+		if err := n.persister.ensure(now); err != nil {
+			return 0, err
+		}
 	}
 	n.lastTimestamp = now
-	ts := getNewestTimestamp(n.lastTimestamp)
-	id = (ts << timestampShift) | (n.workId << workIdShift) | (n.sequence)
+	if now > n.maxTimestamp {
+		n.maxTimestamp = now
+	}
+	id := (ts << n.layout.timestampShift) |
+		(n.rollbackSeq << n.layout.rollbackShift) |
+		(n.datacenterId << n.layout.datacenterShift) |
+		(n.workId << n.layout.workIdShift) |
+		(n.sequence)
 	return id, nil
 }
 
-func wait(lastTimestamp int64) int64 {
-	now := time.Now().UnixMilli()
-	if now <= lastTimestamp {
-		time.Sleep(time.Millisecond * time.Duration(lastTimestamp-now+1))
+// DatacenterId returns the datacenter id of the node
+func (n *Node) DatacenterId() int64 {
+	return n.datacenterId
+}
+
+// WorkId returns the worker id of the node
+func (n *Node) WorkId() int64 {
+	return n.workId
+}
+
+/*
+wait until the next millisecond after lastTimestamp
+*/
+func (n *Node) wait(lastTimestamp int64) int64 {
+	now := n.clock.Now().UnixMilli()
+	for now <= lastTimestamp {
+		n.clock.Sleep(time.Millisecond * time.Duration(lastTimestamp-now+1))
+		now = n.clock.Now().UnixMilli()
 	}
 	return now
 }
 
 /*
-get the newest timestamp relative to twepoch
+get the newest timestamp relative to the epoch
 */
-func getNewestTimestamp(lastTimestamp int64) int64 {
-	return lastTimestamp - twepoch
+func (l layout) elapsed(timestamp int64) int64 {
+	return timestamp - l.epoch
 }
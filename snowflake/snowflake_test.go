@@ -0,0 +1,56 @@
+package snowflake
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNextIds_AcrossSequenceRollover(t *testing.T) {
+	for name := range generators(t) {
+		t.Run(name, func(t *testing.T) {
+			clock := newFakeClock()
+			g := generators(t, WithClock(clock))[name]
+			start := clock.Now().UnixMilli()
+
+			// 时钟不走，序列号用完后只能等到下一毫秒
+			count := 3<<12 + 5
+			ids, err := g.NextIds(count)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ids) != count {
+				t.Fatalf("len = %d, want %d", len(ids), count)
+			}
+			seen := make(map[int64]struct{}, count)
+			for i, id := range ids {
+				if _, ok := seen[id]; ok {
+					t.Fatalf("duplicate id %d at %d", id, i)
+				}
+				seen[id] = struct{}{}
+				if i > 0 && id <= ids[i-1] {
+					t.Fatalf("ids[%d] = %d not greater than ids[%d] = %d", i, id, i-1, ids[i-1])
+				}
+				parts := g.Decompose(id)
+				if want := start + int64(i>>12); parts.Time.UnixMilli() != want || parts.Sequence != int64(i&(1<<12-1)) {
+					t.Fatalf("ids[%d] = %+v, want time %d sequence %d", i, parts, want, i&(1<<12-1))
+				}
+			}
+
+			// 下一批接着上一批
+			next := mustNextId(t, g)
+			if next <= ids[count-1] {
+				t.Fatalf("next id %d not greater than last batch id %d", next, ids[count-1])
+			}
+		})
+	}
+}
+
+func TestNextIds_InvalidCount(t *testing.T) {
+	for name, g := range generators(t) {
+		for _, count := range []int{0, -1} {
+			if _, err := g.NextIds(count); !errors.Is(err, ErrInvalidCount) {
+				t.Errorf("%s: NextIds(%d) err = %v, want ErrInvalidCount", name, count, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/*
+持久化预留的时间戳上界：节点只发不超过上界的时间戳，上界在发号之前落盘，
+因此崩溃后存储的值一定不小于已发出的任何时间戳，重启时钟落后于它时可以检测到
+*/
+
+const defaultPersistInterval = time.Second
+
+// ErrNodeClosed is returned when a node with a TimestampStore is asked for
+// an id past its reserved timestamp after Close
+var ErrNodeClosed = errors.New("snowflake: node closed")
+
+// TimestampStore persists the reserved upper bound, in unix milliseconds, of
+// the timestamps a node may issue
+type TimestampStore interface {
+	// Load returns the stored timestamp, or 0 if nothing was stored yet
+	Load() (int64, error)
+	Save(timestamp int64) error
+}
+
+// FileStore is a TimestampStore backed by a single file
+type FileStore struct {
+	path string
+}
+
+// NewFileStore returns a FileStore writing to path
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func (s *FileStore) Load() (int64, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// Save writes to a temporary file first and renames it, so a crash never
+// leaves a truncated file behind
+func (s *FileStore) Save(timestamp int64) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.FormatInt(timestamp, 10)), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+/*
+restore loads the persisted upper bound and makes sure the clock is past it,
+waiting at most startupWait, it returns the timestamp to resume from
+*/
+func restore(o *options) (int64, error) {
+	if o.store == nil {
+		return 0, nil
+	}
+	highWater, err := o.store.Load()
+	if err != nil {
+		return 0, fmt.Errorf("snowflake: load persisted timestamp: %w", err)
+	}
+	now := o.clock.Now().UnixMilli()
+	if now > highWater {
+		return highWater, nil
+	}
+	// 上界所在的毫秒内可能已用完序列号，因此需要严格大于持久化的时间戳
+	offset := time.Duration(highWater-now+1) * time.Millisecond
+	if offset > o.startupWait {
+		return 0, &ClockBackwardError{Offset: offset}
+	}
+	o.clock.Sleep(offset)
+	return highWater, nil
+}
+
+/*
+persister reserves timestamps ahead of the clock: the bound now+interval is
+saved before any id past the previous bound is issued, a background task
+extends it every half interval so issuing rarely has to wait for a save
+*/
+type persister struct {
+	store    TimestampStore
+	interval time.Duration
+	clock    Clock
+	limit    atomic.Int64 // 已落盘的上界，不超过它的时间戳可以直接发号
+
+	mu     sync.Mutex
+	closed bool
+	stop   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+}
+
+func newPersister(o *options) (*persister, error) {
+	if o.store == nil {
+		return nil, nil
+	}
+	p := &persister{
+		store:    o.store,
+		interval: o.persistInterval,
+		clock:    o.clock,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if err := p.reserve(o.clock.Now().UnixMilli()); err != nil {
+		return nil, fmt.Errorf("snowflake: save persisted timestamp: %w", err)
+	}
+	go p.run()
+	return p, nil
+}
+
+func (p *persister) run() {
+	defer close(p.done)
+	ticker := time.NewTicker(p.interval / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			// 保存失败时等待下一次重试，上界用完后发号路径会同步保存并返回错误
+			p.mu.Lock()
+			if !p.closed {
+				_ = p.reserve(p.clock.Now().UnixMilli())
+			}
+			p.mu.Unlock()
+		}
+	}
+}
+
+/*
+ensure makes sure now is within the saved upper bound, saving a new bound
+first when it is not
+*/
+func (p *persister) ensure(now int64) error {
+	if now <= p.limit.Load() {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if now <= p.limit.Load() {
+		return nil
+	}
+	if p.closed {
+		return ErrNodeClosed
+	}
+	if err := p.reserve(now); err != nil {
+		return fmt.Errorf("snowflake: save persisted timestamp: %w", err)
+	}
+	return nil
+}
+
+/*
+reserve saves now+interval as the new upper bound, the stored bound never
+moves backwards, the caller must hold mu or own p exclusively
+*/
+func (p *persister) reserve(now int64) error {
+	bound := now + p.interval.Milliseconds()
+	if bound <= p.limit.Load() {
+		return nil
+	}
+	if err := p.store.Save(bound); err != nil {
+		return err
+	}
+	p.limit.Store(bound)
+	return nil
+}
+
+/*
+close stops extending the bound, the stored bound is kept as is because ids
+up to it may still be issued concurrently
+*/
+func (p *persister) close() error {
+	p.once.Do(func() {
+		close(p.stop)
+		<-p.done
+		p.mu.Lock()
+		p.closed = true
+		p.mu.Unlock()
+	})
+	return nil
+}
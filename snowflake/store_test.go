@@ -0,0 +1,147 @@
+package snowflake
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memStore is an in-memory TimestampStore that can be told to fail
+type memStore struct {
+	mu      sync.Mutex
+	value   int64
+	saveErr error
+}
+
+func (s *memStore) Load() (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.value, nil
+}
+
+func (s *memStore) Save(timestamp int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.saveErr != nil {
+		return s.saveErr
+	}
+	s.value = timestamp
+	return nil
+}
+
+func (s *memStore) failSaves(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saveErr = err
+}
+
+// storeGenerator builds the named generator alone, a store must not be shared
+// by two nodes
+func storeGenerator(t *testing.T, name string, clock Clock, store TimestampStore) Generator {
+	t.Helper()
+	opts := []Option{WithClock(clock), WithTimestampStore(store), WithPersistInterval(time.Hour)}
+	var (
+		g   Generator
+		err error
+	)
+	if name == "Node" {
+		g, err = New(opts...)
+	} else {
+		g, err = NewAtomic(opts...)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { g.Close() })
+	return g
+}
+
+func TestPersister_ReservesBeforeIssuing(t *testing.T) {
+	for name := range generators(t) {
+		t.Run(name, func(t *testing.T) {
+			clock := newFakeClock()
+			store := &memStore{}
+			g := storeGenerator(t, name, clock, store)
+			if want := clock.Now().UnixMilli() + time.Hour.Milliseconds(); store.value != want {
+				t.Fatalf("saved = %d, want %d", store.value, want)
+			}
+
+			// 越过上界时先同步保存新的上界再发号
+			clock.Step(90 * time.Minute)
+			id := mustNextId(t, g)
+			issued := g.Decompose(id).Time.UnixMilli()
+			if store.value < issued {
+				t.Fatalf("saved = %d, issued %d past it", store.value, issued)
+			}
+		})
+	}
+}
+
+func TestPersister_SaveFailureStopsIssuing(t *testing.T) {
+	for name := range generators(t) {
+		t.Run(name, func(t *testing.T) {
+			clock := newFakeClock()
+			store := &memStore{}
+			g := storeGenerator(t, name, clock, store)
+			saveErr := errors.New("disk full")
+			store.failSaves(saveErr)
+
+			// 上界之内不需要保存
+			mustNextId(t, g)
+			clock.Step(2 * time.Hour)
+			if _, err := g.NextId(); !errors.Is(err, saveErr) {
+				t.Fatalf("err = %v, want %v", err, saveErr)
+			}
+		})
+	}
+}
+
+func TestPersister_ClosedNodeStopsAtBound(t *testing.T) {
+	for name := range generators(t) {
+		t.Run(name, func(t *testing.T) {
+			clock := newFakeClock()
+			g := storeGenerator(t, name, clock, &memStore{})
+			if err := g.Close(); err != nil {
+				t.Fatal(err)
+			}
+			mustNextId(t, g)
+			clock.Step(2 * time.Hour)
+			if _, err := g.NextId(); !errors.Is(err, ErrNodeClosed) {
+				t.Fatalf("err = %v, want ErrNodeClosed", err)
+			}
+		})
+	}
+}
+
+func TestRestore_WaitsPastReservedBound(t *testing.T) {
+	for name := range generators(t) {
+		t.Run(name, func(t *testing.T) {
+			clock := newFakeClock()
+			bound := clock.Now().UnixMilli() + 500
+			g := storeGenerator(t, name, clock, &memStore{value: bound})
+
+			// 启动等待至少为一个持久化间隔，因此无需显式设置 WithStartupWait
+			if want := 501 * time.Millisecond; clock.Slept() != want {
+				t.Fatalf("slept %s, want %s", clock.Slept(), want)
+			}
+			id := mustNextId(t, g)
+			if issued := g.Decompose(id).Time.UnixMilli(); issued <= bound {
+				t.Fatalf("issued %d, want > %d", issued, bound)
+			}
+		})
+	}
+}
+
+func TestRestore_ClockTooFarBehind(t *testing.T) {
+	clock := newFakeClock()
+	store := &memStore{value: clock.Now().UnixMilli() + 10_000}
+	_, err := New(WithClock(clock), WithTimestampStore(store), WithPersistInterval(time.Second))
+	var backward *ClockBackwardError
+	if !errors.As(err, &backward) {
+		t.Fatalf("err = %v, want *ClockBackwardError", err)
+	}
+	if backward.Offset != 10_001*time.Millisecond {
+		t.Fatalf("offset = %s, want 10.001s", backward.Offset)
+	}
+}